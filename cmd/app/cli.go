@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"parabellum.crawler/internal/crawler"
+	"parabellum.crawler/internal/model"
+	"parabellum.crawler/internal/store"
+)
+
+//commands CLI subcommands run instead of the service, e.g. "app graph -url https://site/"
+var commands = map[string]func(args []string) error{
+	"graph":     runGraphCommand,
+	"tasks":     runTasksCommand,
+	"endpoints": runEndpointsCommand,
+	"diff":      runDiffCommand,
+}
+
+//runCommand runs CLI subcommand if it's given in args, returns false if the service should be run
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	command, ok := commands[args[0]]
+	if !ok {
+		return false
+	}
+
+	if err := command(args[1:]); err != nil {
+		log.Fatalf("Error running %s command:\t%v\n", args[0], err)
+	}
+
+	return true
+}
+
+//runGraphCommand crawls a given url & exports its site graph
+func runGraphCommand(args []string) error {
+	flags := flag.NewFlagSet("graph", flag.ContinueOnError)
+	target := flags.String("url", "", "url to crawl")
+	format := flags.String("format", crawler.GraphFormatDOT, "graph format: dot, graphml or json")
+	output := flags.String("out", "", "file to write the graph to, stdout if empty")
+	depth := flags.Int("depth", 0, "max depth of visiting links, service default if 0")
+	pages := flags.Int("pages", 0, "max number of pages to visit, service default if 0")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *target == "" {
+		return fmt.Errorf("url is required")
+	}
+
+	cr, err := crawlStandalone(*target, &model.CrawlOptions{MaxDepth: *depth, MaxPages: *pages, ExportGraph: *format})
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		return cr.Graph.Write(os.Stdout, *format)
+	}
+
+	return writeGraphFile(cr.Graph, *output, *format)
+}
+
+//runTasksCommand prints tasks saved in the store as json, the latest first
+func runTasksCommand(args []string) error {
+	flags := flag.NewFlagSet("tasks", flag.ContinueOnError)
+	storePath := flags.String("store", EnvVarOfType("CRAWLER_STORE", TypeString).(string), "store file to read")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	return queryStore(*storePath, func(crawlStore store.CrawlStore) (any, error) {
+		return crawlStore.Tasks()
+	})
+}
+
+//runEndpointsCommand prints endpoints (or edges) of a task saved in the store as json
+func runEndpointsCommand(args []string) error {
+	flags := flag.NewFlagSet("endpoints", flag.ContinueOnError)
+	storePath := flags.String("store", EnvVarOfType("CRAWLER_STORE", TypeString).(string), "store file to read")
+	taskID := flags.String("task", "", "task id")
+	edges := flags.Bool("edges", false, "print links between endpoints instead of endpoints")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *taskID == "" {
+		return fmt.Errorf("task is required")
+	}
+
+	return queryStore(*storePath, func(crawlStore store.CrawlStore) (any, error) {
+		if *edges {
+			return crawlStore.Edges(*taskID)
+		}
+
+		return crawlStore.Endpoints(*taskID)
+	})
+}
+
+//runDiffCommand prints difference between two tasks saved in the store as json
+func runDiffCommand(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	storePath := flags.String("store", EnvVarOfType("CRAWLER_STORE", TypeString).(string), "store file to read")
+	fromID := flags.String("from", "", "old task id, the previous crawl of the same url if empty")
+	toID := flags.String("to", "", "new task id")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *toID == "" {
+		return fmt.Errorf("to is required")
+	}
+
+	return queryStore(*storePath, func(crawlStore store.CrawlStore) (any, error) {
+		if *fromID == "" {
+			task, err := crawlStore.Task(*toID)
+			if err != nil {
+				return nil, err
+			}
+			prev, err := store.PreviousTask(crawlStore, task)
+			if err != nil {
+				return nil, fmt.Errorf("no previous crawl of %s: %w", task.URL, err)
+			}
+			*fromID = prev.ID
+		}
+
+		return store.DiffTasks(crawlStore, *fromID, *toID)
+	})
+}
+
+//queryStore opens a given store file & prints result of a given query as json
+func queryStore(storePath string, query func(crawlStore store.CrawlStore) (any, error)) error {
+	if storePath == "" {
+		return fmt.Errorf("store is required, set CRAWLER_STORE or -store")
+	}
+	if _, err := os.Stat(storePath); err != nil {
+		return fmt.Errorf("error opening store: %w", err)
+	}
+	crawlStore, err := store.OpenBoltStore(storePath)
+	if err != nil {
+		return err
+	}
+
+	result, err := query(crawlStore)
+	if err == nil {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(result)
+	}
+
+	return closeAfter(crawlStore, err)
+}
+
+//crawlStandalone crawls a given url with task options outside of the service
+func crawlStandalone(target string, opts *model.CrawlOptions) (*crawler.Crawler, error) {
+	providedURL, err := url.Parse(target)
+	if err != nil {
+		return nil, fmt.Errorf("wrong url %s: %w", target, err)
+	}
+	settings, err := NewCrawlSettings(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), settings.Timeout)
+	defer cancel()
+	cr := crawler.NewCrawler(ctx, providedURL)
+	settings.Apply(cr)
+	log.Printf("Crawling on: %s.\n", providedURL.String())
+	cr.ExploreLink(crawler.NewLink(providedURL.String()))
+	cr.Wait()
+
+	return cr, nil
+}
+
+//writeGraphFile exports site graph into a given file, creating its directory if needed
+func writeGraphFile(graph *crawler.SiteGraph, path, format string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating graph directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating graph file: %w", err)
+	}
+
+	return closeAfter(file, graph.Write(file, format))
+}
+
+//closeAfter closes a given closer, returns the first error of err & closing error
+func closeAfter(closer io.Closer, err error) error {
+	if closeErr := closer.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+	"parabellum.crawler/internal/cluster"
+	"parabellum.crawler/internal/crawler"
+	"parabellum.crawler/internal/model"
+	"parabellum.crawler/internal/pubsub"
+)
+
+//isDistributed returns true if tasks are crawled by all replicas of the service, see [cluster.Node]
+func isDistributed() bool {
+	return EnvVarOfType("CRAWLER_DISTRIBUTED", TypeInt).(int) > 0
+}
+
+//initCluster starts visiting frontier links of the replica's shards in distributed mode, until ctx is done
+func (app *Config) initCluster(ctx context.Context) {
+	if !isDistributed() {
+		return
+	}
+	kafkaURL := EnvVarOfType("KAFKA_URL", TypeString).(string)
+	topicFrontier := EnvVarOfType("KAFKA_TOPIC_FRONTIER", TypeString).(string)
+	topicEvents := EnvVarOfType("KAFKA_TOPIC_EVENTS", TypeString).(string)
+
+	frontier := pubsub.NewConsumer(pubsub.RealKafkaGroupReader(kafkaURL, topicFrontier, "crawler-frontier", false), topicFrontier)
+	links := pubsub.NewProducer(pubsub.RealKafkaHashWriter(kafkaURL, topicFrontier), topicFrontier)
+	events := pubsub.NewProducer(pubsub.RealKafkaHashWriter(kafkaURL, topicEvents), topicEvents)
+	//every replica reads all events with its own group, only events published after start are needed
+	progress := pubsub.NewConsumer(pubsub.RealKafkaGroupReader(kafkaURL, topicEvents, "crawler-events-"+uuid.NewString(), true), topicEvents)
+	app.ClusterPubSub = []io.Closer{frontier, links, events, progress}
+
+	threads := EnvVarOfType("CRAWLER_NUM_OF_THREADS", TypeInt).(int)
+	replicas := EnvVarOfType("CRAWLER_REPLICAS", TypeInt).(int)
+	app.Node = cluster.NewNode(frontier, links, events, progress, threads, replicas, newShardCrawler)
+	go app.Node.Run(ctx)
+	log.Printf("Crawling in distributed mode, frontier topic:\t%s\n", topicFrontier)
+}
+
+//newShardCrawler returns crawler of the replica's shard of a given task, see [main.shardTask]
+func newShardCrawler(ctx context.Context, task *model.TaskConsume) (*crawler.Crawler, error) {
+	taskURL, err := url.Parse(task.URL)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := NewCrawlSettings(task.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	result := crawler.NewCrawler(ctx, taskURL)
+	settings.Apply(result)
+	result.Graph, result.Recorder = nil, nil
+	addPassiveChecks(result, task.ForwardTo)
+	if task.SkipCrawler {
+		result.MaxJumps = 0
+	}
+
+	return result, nil
+}
+
+//crawlDistributed crawls a task with all replicas, pages of their shards are collected into app.Crawler
+func (app *Config) crawlDistributed(ctx context.Context, task *model.TaskConsume, settings *CrawlSettings) error {
+	if app.Crawler.Graph != nil || app.Crawler.Recorder != nil || settings.Incremental {
+		log.Printf("Site graph, HAR recording & incremental crawl are not supported in distributed mode, skipped for task ID: %s\n", task.ID)
+	}
+	app.Crawler.Graph, app.Crawler.Recorder = nil, nil
+
+	var seeds []*crawler.Link
+	if settings.SeedHAR != nil {
+		seeds = settings.SeedHAR.SeedLinks()
+		for _, l := range seeds {
+			l.Extractor = crawler.ExtractorSeed
+		}
+	}
+
+	return app.Node.Crawl(ctx, shardTask(task, app.Crawler.Headers), app.Crawler, crawler.NewLink(app.Crawler.URL.String()), seeds)
+}
+
+//shardTask returns a copy of a given task for shards of replicas: seed HAR is read by the coordinator only,
+//so its session headers are passed to shards in headers option with other request headers of the crawl
+func shardTask(task *model.TaskConsume, headers http.Header) *model.TaskConsume {
+	result := *task
+	opts := model.CrawlOptions{}
+	if task.Options != nil {
+		opts = *task.Options
+	}
+	opts.SeedHAR = ""
+	opts.Headers = make(map[string]string, len(headers))
+	for key := range headers {
+		opts.Headers[key] = headers.Get(key)
+	}
+	result.Options = &opts
+
+	return &result
+}
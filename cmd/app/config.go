@@ -2,22 +2,28 @@ package main
 
 import (
 	"context"
+	"errors"
+	"io"
 	"log"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"time"
 
+	"parabellum.crawler/internal/cluster"
 	"parabellum.crawler/internal/crawler"
 	"parabellum.crawler/internal/model"
 	"parabellum.crawler/internal/network"
 	"parabellum.crawler/internal/pubsub"
+	"parabellum.crawler/internal/store"
 )
 
 const (
 	TypeString = iota
 	TypeInt
 	TypeTimeSecond
+	TypeFloat
 )
 
 type TestTopicName string
@@ -28,23 +34,82 @@ const (
 	Topic_SQLI = TestTopicName("SQLI-check")
 	Topic_BA   = TestTopicName("BA-check")
 	Topic_LFI  = TestTopicName("LFI-check")
+
+	Topic_Redirect = TestTopicName("REDIRECT-check")
+
+	Topic_Headers    = TestTopicName("HEADERS-check")
+	Topic_Cookies    = TestTopicName("COOKIES-check")
+	Topic_Disclosure = TestTopicName("DISCLOSURE-check")
+	Topic_Probe      = TestTopicName("PROBE-check")
+	Topic_Secrets    = TestTopicName("SECRETS-check")
+	Topic_Mixed      = TestTopicName("MIXED-CONTENT-check")
+	Topic_Forms      = TestTopicName("FORMS-check")
+
+	Topic_Diff = TestTopicName("CRAWL-DIFF")
 )
 
 var envDefaults = map[string]string{
-	"KAFKA_URL":               "localhost:9092",
-	"KAFKA_TOPIC_API":         "API-Service-Message",
-	"CRAWLER_DEFAULT_TIMEOUT": "60",
-	"CRAWLER_NUM_OF_THREADS":  "50",
-	"CRAWLER_MAX_DEPTH":       "5",
-	"GRPC_ADDR":               ":9090",
+	"KAFKA_URL":                     "localhost:9092",
+	"KAFKA_TOPIC_API":               "API-Service-Message",
+	"CRAWLER_DEFAULT_TIMEOUT":       "60",
+	"CRAWLER_NUM_OF_THREADS":        "50",
+	"CRAWLER_MAX_DEPTH":             "5",
+	"CRAWLER_MAX_PAGES":             "1000",
+	"CRAWLER_RATE_LIMIT":            "0",
+	"CRAWLER_PRIORITY":              "bfs",
+	"CRAWLER_USER_AGENT":            "parabellum.crawler",
+	"CRAWLER_MAX_TOTAL_BYTES":       "104857600",
+	"CRAWLER_MAX_BODY_BYTES":        "5242880",
+	"CRAWLER_MAX_URLS_PER_PATTERN":  "100",
+	"CRAWLER_GROUP_REPRESENTATIVES": "3",
+	"CRAWLER_GROUP_MAX_DISTANCE":    "3",
+	"CRAWLER_DISCLOSURE_RULES":      "",
+	"CRAWLER_SECRET_RULES":          "",
+	"CRAWLER_PROBE_WORDLIST":        "",
+	"CRAWLER_MAX_PROBES":            "500",
+	"CRAWLER_DETECT_SOFT404":        "1",
+	"CRAWLER_MAX_REDIRECTS":         "10",
+	"CRAWLER_PARSE_STATUS_CLASSES":  "2xx,4xx,5xx",
+	"CRAWLER_RECORD_HAR":            "0",
+	"CRAWLER_ARTIFACTS_DIR":         "artifacts",
+	"CRAWLER_HAR_MAX_BODY_BYTES":    "65536",
+	"CRAWLER_HAR_MAX_TOTAL_BYTES":   "67108864",
+	"CRAWLER_STORE":                 "",
+	"CRAWLER_CHECKPOINT_DIR":        "checkpoints",
+	"CRAWLER_CHECKPOINT_INTERVAL":   "30",
+	"CRAWLER_DETECT_REFLECTIONS":    "0",
+	"CRAWLER_DISTRIBUTED":           "0",
+	"CRAWLER_REPLICAS":              "1",
+	"KAFKA_TOPIC_FRONTIER":          "CRAWLER-FRONTIER",
+	"KAFKA_TOPIC_EVENTS":            "CRAWLER-EVENTS",
+	"CRAWLER_MAX_CANARIES":          "500",
+	"CRAWLER_LIMIT_TIMEOUT":         "600",
+	"CRAWLER_LIMIT_NUM_OF_THREADS":  "200",
+	"CRAWLER_LIMIT_MAX_DEPTH":       "10",
+	"CRAWLER_LIMIT_MAX_PAGES":       "10000",
+	"CRAWLER_LIMIT_RATE":            "0",
+	"GRPC_ADDR":                     ":9090",
+}
+
+//envFallbacks previous names of renamed env variables, a value of the previous name is used if the variable is unset
+var envFallbacks = map[string]string{
+	"CRAWLER_ARTIFACTS_DIR": "CRAWLER_HAR_DIR",
 }
 
 //Config represents the core application structure
 type Config struct {
 	Crawler    *crawler.Crawler                   //crawler to visit links on a given url
+	Settings   *CrawlSettings                     //crawl settings of the current task
+	HARFile    string                             //HAR file of the current task, empty if not recorded
+	GraphFile  string                             //site graph file of the current task, empty if not exported
+	Previous   string                             //id of the previous crawl of the current task, empty if crawl is not incremental
+	Diff       *store.CrawlDiff                   //difference of the current task with the previous crawl, nil if not requested
 	Consumer   *pubsub.Consumer                   //to read tasks for the app from pubsub
 	Producers  map[TestTopicName]*pubsub.Producer //to push tasks for test-services
 	ClientGrpc *network.ClientGRPC                //to push 5xx errors directly to result collector
+
+	Node          *cluster.Node //replica of distributed crawl, nil if tasks are crawled by one replica
+	ClusterPubSub []io.Closer   //readers & writers of distributed crawl topics
 }
 
 func init() {
@@ -55,6 +120,17 @@ func init() {
 }
 
 func setEnvDefaults() error {
+	for env, previous := range envFallbacks {
+		val, ok := os.LookupEnv(previous)
+		if _, isSet := os.LookupEnv(env); isSet || !ok {
+			continue
+		}
+		log.Printf("%s is deprecated, use %s instead\n", previous, env)
+		if err := os.Setenv(env, val); err != nil {
+			return err
+		}
+	}
+
 	var err error
 	for env, val := range envDefaults {
 		if _, ok := os.LookupEnv(env); !ok {
@@ -68,26 +144,67 @@ func setEnvDefaults() error {
 	return err
 }
 
-//TestFilters represents how endpoints should be filtered between tests
-var TestsFilters = map[TestTopicName][crawler.NumOfBodyParams]bool{
-	Topic_SQLI: fillTestFilter("SQLi" /*HasFormTag*/, true /*HasQueryParameter*/, false /*HasStatusError*/, false),
-	Topic_BA:   fillTestFilter("Broken auth", true, false, false),
-	Topic_XSS:  fillTestFilter("XSS", true, true, false),
-	Topic_LFI:  fillTestFilter("LFI", false, true, false),
-	Topic_5XX:  fillTestFilter("5xx", false, false, true),
+//TestsFilters represents how endpoints should be filtered between tests: url is forwarded if it has any of the features
+var TestsFilters = map[TestTopicName][]crawler.Feature{
+	Topic_SQLI:     {crawler.FeatureForm},
+	Topic_BA:       {crawler.FeatureForm},
+	Topic_XSS:      {crawler.FeatureForm, crawler.FeatureQueryParams},
+	Topic_LFI:      {crawler.FeatureQueryParams},
+	Topic_5XX:      {crawler.FeatureStatusError},
+	Topic_Redirect: {crawler.FeatureRedirectParam},
+}
+
+//PassiveChecks represents checks run on every crawled page, their findings are pushed directly to result collector
+var PassiveChecks = map[TestTopicName]crawler.PassiveCheck{
+	Topic_Headers: crawler.SecurityHeadersCheck{},
+	Topic_Cookies: crawler.CookieCheck{},
+	Topic_Mixed:   crawler.MixedContentCheck{},
+	Topic_Forms:   crawler.InsecureFormCheck{},
+}
+
+//ProbeWordlist represents paths requested under discovered directories when Topic_Probe is requested
+var ProbeWordlist []*crawler.ProbeEntry
+
+//initChecks loads checks configured from files
+func initChecks() {
+	rules, err := crawler.LoadDisclosureRules(EnvVarOfType("CRAWLER_DISCLOSURE_RULES", TypeString).(string))
+	if err != nil {
+		log.Panicln("Error loading disclosure rules\t", err)
+	}
+	PassiveChecks[Topic_Disclosure] = crawler.NewDisclosureCheck(rules)
+
+	secretRules, err := crawler.LoadSecretRules(EnvVarOfType("CRAWLER_SECRET_RULES", TypeString).(string))
+	if err != nil {
+		log.Panicln("Error loading secret rules\t", err)
+	}
+	PassiveChecks[Topic_Secrets] = crawler.NewSecretsCheck(secretRules)
+
+	ProbeWordlist, err = crawler.LoadProbeWordlist(EnvVarOfType("CRAWLER_PROBE_WORDLIST", TypeString).(string))
+	if err != nil {
+		log.Panicln("Error loading probe wordlist\t", err)
+	}
 }
 
-func fillTestFilter(testName string, filters ...bool) [crawler.NumOfBodyParams]bool {
-	if len(filters) != crawler.NumOfBodyParams {
-		log.Panicln("Wrong filters number for test", testName)
+//findingTypeOf returns type of findings pushed to result collector for a given test name
+func findingTypeOf(topic TestTopicName) (string, bool) {
+	if check, ok := PassiveChecks[topic]; ok {
+		return check.Type(), true
 	}
+	if topic == Topic_Probe {
+		return crawler.FindingSensitiveFile, true
+	}
+
+	return "", false
+}
 
-	var result [crawler.NumOfBodyParams]bool
-	for i := 0; i < crawler.NumOfBodyParams; i++ {
-		result[i] = filters[i]
+func isRequested(tests []string, topic TestTopicName) bool {
+	for _, tName := range tests {
+		if TestTopicName(tName) == topic {
+			return true
+		}
 	}
 
-	return result
+	return false
 }
 
 //EnvVarOfType returns environment variable converted to a given type
@@ -104,6 +221,10 @@ func EnvVarOfType(varName string, varType int) any {
 		res, _ := strconv.Atoi(strVal)
 
 		return time.Duration(res) * time.Second
+	case TypeFloat:
+		res, _ := strconv.ParseFloat(strVal, 64)
+
+		return res
 	}
 
 	return nil
@@ -121,8 +242,15 @@ func (app *Config) ExecuteNextTask(exitCtx context.Context) error {
 		return err
 	}
 
-	ctx, cancel := context.WithTimeout(exitCtx, EnvVarOfType("CRAWLER_DEFAULT_TIMEOUT", TypeTimeSecond).(time.Duration))
-	err = app.doCrawlerJob(ctx, taskInfo.Value.URL, taskInfo.Value.SkipCrawler)
+	settings, err := NewCrawlSettings(taskInfo.Value.Options)
+	if err != nil {
+		log.Printf("Wrong crawl options for task ID: %s\t%v\n", taskInfo.Value.ID, err)
+
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(exitCtx, settings.Timeout)
+	err = app.doCrawlerJob(ctx, taskInfo.Value, settings)
 	cancel()
 	if err != nil {
 		return err
@@ -138,6 +266,7 @@ func (app *Config) ExecuteNextTask(exitCtx context.Context) error {
 		log.Printf("Failed to commit task ID: %s \t%v\n", taskInfo.Value.ID, err)
 	} else {
 		log.Printf("Done with task ID: %s\n", taskInfo.Value.ID)
+		app.removeCheckpoint(taskInfo.Value.ID)
 	}
 
 	return err
@@ -155,6 +284,7 @@ func (app *Config) initPubSub() {
 		kafkaWriter := pubsub.RealKafkaWriter(kafkaURL, topicName)
 		app.Producers[testName] = pubsub.NewProducer(kafkaWriter, topicName)
 	}
+	app.Producers[Topic_Diff] = pubsub.NewProducer(pubsub.RealKafkaWriter(kafkaURL, string(Topic_Diff)), string(Topic_Diff))
 }
 
 func (app *Config) closePubSub() {
@@ -162,89 +292,226 @@ func (app *Config) closePubSub() {
 	for _, prod := range app.Producers {
 		_ = prod.Close()
 	}
+	for _, closer := range app.ClusterPubSub {
+		_ = closer.Close()
+	}
 }
 
-func (app *Config) doCrawlerJob(ctx context.Context, siteName string, skipCrawling bool) error {
-	providedURL, err := url.Parse(siteName)
-	if err != nil {
-		log.Printf("Wrong site name provided %s: %v\n", siteName, err)
+//taskFilePath returns path of a task file with a given extension in the directory of a given env variable
+func taskFilePath(dirEnv, taskID, ext string) string {
+	return filepath.Join(EnvVarOfType(dirEnv, TypeString).(string), filepath.Base(filepath.Clean("/"+taskID))+"."+ext)
+}
 
-		return err
+//writeArtifact writes task artifact with a given extension into artifacts directory, returns its path or empty string on error
+func (app *Config) writeArtifact(taskID, ext string, write func(path string) error) string {
+	path := taskFilePath("CRAWLER_ARTIFACTS_DIR", taskID, ext)
+	if err := write(path); err != nil {
+		log.Printf("Error writing %s artifact for task ID: %s\t%v\n", ext, taskID, err)
+
+		return ""
 	}
-	app.Crawler = crawler.NewCrawler(ctx, providedURL)
-	app.Crawler.MaxJumps = EnvVarOfType("CRAWLER_MAX_DEPTH", TypeInt).(int)
-	if skipCrawling {
-		app.Crawler.MaxJumps = 0
+
+	return path
+}
+
+//saveCrawl saves results of the current task into the store file of CRAWLER_STORE, if it's set, & calculates app.Diff if requested
+//the store is opened for every task, so it can be queried with CLI commands between tasks
+func (app *Config) saveCrawl(task *model.TaskConsume, started time.Time) {
+	app.Diff = nil
+	diffRequested := isRequested(task.ForwardTo, Topic_Diff)
+	storePath := EnvVarOfType("CRAWLER_STORE", TypeString).(string)
+	if storePath == "" {
+		if diffRequested {
+			log.Printf("Crawl diff requires CRAWLER_STORE, skipped for task ID: %s\n", task.ID)
+		}
+
+		return
 	}
-	app.Crawler.SetNumberOfThreads(EnvVarOfType("CRAWLER_NUM_OF_THREADS", TypeInt).(int))
-	log.Printf("Crawling on: %s.\n", providedURL.String())
-	app.Crawler.ExploreLink(crawler.NewLink(providedURL.String()))
-	app.Crawler.Wait()
 
-	if skipCrawling {
-		app.Crawler.Result.Range(func(key, value any) bool {
-			if curResponse, ok := value.(*crawler.Response); ok {
-				for i := 0; i < crawler.NumOfBodyParams; i++ {
-					curResponse.BodyParams[i] = true
-				}
-			}
+	crawlStore, err := store.OpenBoltStore(storePath)
+	if err != nil {
+		log.Printf("Error saving results of task ID: %s\t%v\n", task.ID, err)
 
-			return true
-		})
+		return
 	}
+	defer crawlStore.Close()
 
-	return nil
-}
+	crawl := store.NewCrawl(task.ID, started, app.Crawler)
+	if err = crawlStore.SaveCrawl(crawl); err != nil {
+		log.Printf("Error saving results of task ID: %s\t%v\n", task.ID, err)
+
+		return
+	}
+	if !diffRequested {
+		return
+	}
 
-func (app *Config) distributeResultsBetweenTests(tests []string) (map[TestTopicName][]string, []*crawler.Response) {
-	var responses5xx []*crawler.Response
-	resForTests := map[TestTopicName][]string{}
+	var fromID string
+	if prev, err := store.PreviousTask(crawlStore, crawl.Task); err == nil {
+		fromID = prev.ID
+	}
+	if app.Diff, err = store.DiffTasks(crawlStore, fromID, task.ID); err != nil {
+		log.Printf("Error comparing task ID: %s with previous crawl\t%v\n", task.ID, err)
+	}
+}
 
-	app.Crawler.Result.Range(func(link, value any) bool {
-		if curResponse, ok := value.(*crawler.Response); ok {
-			for _, tName := range tests {
-				topic := TestTopicName(tName)
-				tParam := TestsFilters[topic]
+//loadPrevious sets pages of the latest crawl of the crawler url saved in the store for incremental crawling, returns id of its task
+func (app *Config) loadPrevious() string {
+	target := app.Crawler.URL.String()
+	storePath := EnvVarOfType("CRAWLER_STORE", TypeString).(string)
+	if storePath == "" {
+		log.Printf("Incremental crawl requires CRAWLER_STORE, crawling from scratch:\t%s\n", target)
 
-				if curResponse.HasEqualParamsWith(tParam) {
-					if topic == Topic_5XX {
-						responses5xx = append(responses5xx, curResponse)
+		return ""
+	}
+	if _, err := os.Stat(storePath); err != nil {
+		return ""
+	}
 
-						continue
-					}
+	crawlStore, err := store.OpenBoltStore(storePath)
+	if err != nil {
+		log.Printf("Error loading previous crawl of:\t%s\t%v\n", target, err)
 
-					resForTests[topic] = append(resForTests[topic], curResponse.VisitedLink.URL)
-				}
-			}
+		return ""
+	}
+	task, err := store.LatestTask(crawlStore, target)
+	if err == nil {
+		app.Crawler.Previous, err = store.PreviousPages(crawlStore, task.ID)
+	}
+	if err = closeAfter(crawlStore, err); err != nil {
+		if !errors.Is(err, store.ErrTaskNotFound) {
+			log.Printf("Error loading previous crawl of:\t%s\t%v\n", target, err)
 		}
+		app.Crawler.Previous = nil
+
+		return ""
+	}
+	log.Printf("Comparing crawl with task ID: %s\t%d pages\n", task.ID, len(app.Crawler.Previous))
+
+	return task.ID
+}
 
-		return true
-	})
+//checkpointInterval returns interval of crawl checkpoints, 0 - checkpoints are disabled
+func checkpointInterval() time.Duration {
+	return EnvVarOfType("CRAWLER_CHECKPOINT_INTERVAL", TypeTimeSecond).(time.Duration)
+}
+
+//resumeFromCheckpoint resumes the crawl from checkpoint of a redelivered task & keeps checkpointing the crawl, if checkpoints are enabled
+func (app *Config) resumeFromCheckpoint(taskID string) {
+	if checkpointInterval() <= 0 {
+		return
+	}
+	path := taskFilePath("CRAWLER_CHECKPOINT_DIR", taskID, "json")
+
+	cp, err := crawler.LoadCheckpoint(path)
+	switch {
+	case err != nil:
+		log.Printf("Error loading checkpoint of task ID: %s, crawling from scratch\t%v\n", taskID, err)
+	case cp != nil && cp.URL != app.Crawler.URL.String():
+		log.Printf("Checkpoint of task ID: %s is for another url, crawling from scratch\t%s\n", taskID, cp.URL)
+	case cp != nil:
+		app.Crawler.Resume(cp)
+	}
+	app.Crawler.KeepCheckpoint(path, checkpointInterval())
+}
 
+//removeCheckpoint removes checkpoint of a completed task
+func (app *Config) removeCheckpoint(taskID string) {
+	if checkpointInterval() <= 0 {
+		return
+	}
+	if err := os.Remove(taskFilePath("CRAWLER_CHECKPOINT_DIR", taskID, "json")); err != nil && !os.IsNotExist(err) {
+		log.Printf("Error removing checkpoint of task ID: %s\t%v\n", taskID, err)
+	}
+}
+
+//addPassiveChecks adds passive checks of requested tests into a given crawler
+func addPassiveChecks(cr *crawler.Crawler, tests []string) {
 	for _, tName := range tests {
-		topic := TestTopicName(tName)
-		if _, ok := resForTests[topic]; !ok {
-			resForTests[topic] = []string{}
+		if check, ok := PassiveChecks[TestTopicName(tName)]; ok {
+			cr.Checks = append(cr.Checks, check)
 		}
 	}
-
-	return resForTests, responses5xx
 }
 
-func (app *Config) publishCompletedResults(ctx context.Context, mainTaskID string, tests []string) error {
-	var err error
-	resForTests, responses5xx := app.distributeResultsBetweenTests(tests)
+func (app *Config) doCrawlerJob(ctx context.Context, task *model.TaskConsume, settings *CrawlSettings) error {
+	providedURL, err := url.Parse(task.URL)
+	if err != nil {
+		log.Printf("Wrong site name provided %s: %v\n", task.URL, err)
+
+		return err
+	}
+	started := time.Now()
+	skipCrawling := task.SkipCrawler
+	app.Crawler = crawler.NewCrawler(ctx, providedURL)
+	app.Settings = settings
+	settings.Apply(app.Crawler)
+	if app.Crawler.Graph == nil && app.Node == nil && EnvVarOfType("CRAWLER_STORE", TypeString).(string) != "" {
+		app.Crawler.Graph = crawler.NewSiteGraph() //edges are saved into the store
+	}
+	app.Previous = ""
+	if settings.Incremental && app.Node == nil {
+		app.Previous = app.loadPrevious()
+	}
+	addPassiveChecks(app.Crawler, task.ForwardTo)
+	if skipCrawling {
+		app.Crawler.MaxJumps = 0
+	}
+	log.Printf("Crawling on: %s.\n", providedURL.String())
+	if app.Node != nil {
+		if err = app.crawlDistributed(ctx, task, settings); err != nil {
+			log.Printf("Error crawling task ID: %s in distributed mode\t%v\n", task.ID, err)
 
-	for tName, tTask := range resForTests {
-		if tName == Topic_5XX {
-			err = app.ClientGrpc.Push5XXResult(ctx, mainTaskID, responses5xx)
-		} else {
-			err = app.Producers[tName].PublicMessage(ctx, model.NewMessageProduce(mainTaskID, tTask))
+			return err
 		}
-		if err != nil {
-			log.Printf("Error publishing task for\t%s:\t%v\n", tName, err)
+	} else {
+		app.resumeFromCheckpoint(task.ID)
+		app.Crawler.ExploreLink(crawler.NewLink(providedURL.String()))
+		if settings.SeedHAR != nil {
+			app.Crawler.Seed(settings.SeedHAR.SeedLinks())
 		}
+		app.Crawler.Wait()
 	}
+	if errors.Is(ctx.Err(), context.Canceled) && checkpointInterval() > 0 && app.Node == nil {
+		//service is terminating, the task is not committed & will be resumed from the checkpoint on redelivery
+		if err = app.Crawler.Checkpoint().Write(taskFilePath("CRAWLER_CHECKPOINT_DIR", task.ID, "json")); err != nil {
+			log.Printf("Error writing checkpoint of task ID: %s\t%v\n", task.ID, err)
+		}
 
-	return err
+		return ctx.Err()
+	}
+	if reason := app.Crawler.StopReason(); reason != "" {
+		log.Printf("Crawling on %s was not completed:\t%s\n", providedURL.String(), reason)
+	}
+	if isRequested(task.ForwardTo, Topic_Probe) {
+		log.Printf("Probing sensitive paths on: %s.\n", providedURL.String())
+		app.Crawler.Probe(ProbeWordlist)
+	}
+	if settings.DetectReflections && isRequested(task.ForwardTo, Topic_XSS) {
+		log.Printf("Detecting reflected parameters on: %s.\n", providedURL.String())
+		app.Crawler.DetectReflections()
+	}
+
+	app.HARFile, app.GraphFile = "", ""
+	if app.Crawler.Recorder != nil {
+		app.HARFile = app.writeArtifact(task.ID, "har", app.Crawler.Recorder.WriteHAR)
+	}
+	if app.Crawler.Graph != nil {
+		app.GraphFile = app.writeArtifact(task.ID, settings.ExportGraph, func(path string) error {
+			return writeGraphFile(app.Crawler.Graph, path, settings.ExportGraph)
+		})
+	}
+
+	if skipCrawling {
+		app.Crawler.Result.Range(func(key, value any) bool {
+			if curResponse, ok := value.(*crawler.Response); ok {
+				curResponse.SetFeature(crawler.RegisteredFeatures()...)
+			}
+
+			return true
+		})
+	}
+	app.saveCrawl(task, started)
+
+	return nil
 }
@@ -11,13 +11,19 @@ import (
 )
 
 func main() {
+	if runCommand(os.Args[1:]) {
+		return
+	}
+
 	app := new(Config)
 
+	initChecks()
 	app.initPubSub()
 	defer app.closePubSub()
 
 	exitCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
+	app.initCluster(exitCtx)
 
 	app.ClientGrpc = network.NewClient(os.Getenv("GRPC_ADDR"))
 	defer app.ClientGrpc.Close()
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"parabellum.crawler/internal/crawler"
+	"parabellum.crawler/internal/model"
+	"parabellum.crawler/internal/store"
+)
+
+func (app *Config) distributeResultsBetweenTests(tests []string) (map[TestTopicName][]*crawler.Response, []*crawler.Response) {
+	var responses5xx []*crawler.Response
+	resForTests := map[TestTopicName][]*crawler.Response{}
+
+	app.Crawler.Result.Range(func(link, value any) bool {
+		if curResponse, ok := value.(*crawler.Response); ok {
+			if curResponse.IsScript() {
+				return true
+			}
+			if curResponse.Soft404 && !app.includeSoft404() {
+				return true
+			}
+			if curResponse.Change == crawler.ChangeUnchanged && app.changedOnly() {
+				return true
+			}
+			for _, tName := range tests {
+				topic := TestTopicName(tName)
+				if curResponse.HasAnyFeature(TestsFilters[topic]...) {
+					if topic == Topic_5XX {
+						responses5xx = append(responses5xx, curResponse)
+
+						continue
+					}
+
+					resForTests[topic] = append(resForTests[topic], curResponse)
+				}
+			}
+		}
+
+		return true
+	})
+
+	for _, tName := range tests {
+		topic := TestTopicName(tName)
+		_, isTest := TestsFilters[topic]
+		if _, ok := resForTests[topic]; !ok && isTest {
+			resForTests[topic] = []*crawler.Response{}
+		}
+	}
+
+	return resForTests, responses5xx
+}
+
+//changedOnly returns true if only pages new or changed since the previous crawl should be forwarded to tests
+func (app *Config) changedOnly() bool {
+	return app.Settings != nil && app.Settings.ChangedOnly
+}
+
+//includeSoft404 returns true if pages looking like "not found" responses should be forwarded to tests
+func (app *Config) includeSoft404() bool {
+	return app.Settings != nil && app.Settings.IncludeSoft404
+}
+
+//publishCompletedResults publishes results of the task to requested tests, returns the first error, other results are published anyway
+func (app *Config) publishCompletedResults(ctx context.Context, mainTaskID string, tests []string) error {
+	var result error
+	failed := func(format string, topic TestTopicName, err error) {
+		log.Printf(format, topic, err)
+		if result == nil {
+			result = fmt.Errorf("%s: %w", topic, err)
+		}
+	}
+	resForTests, responses5xx := app.distributeResultsBetweenTests(tests)
+	templates := app.Crawler.EndpointTemplates()
+
+	for tName, responses := range resForTests {
+		var err error
+		if tName == Topic_5XX {
+			err = app.ClientGrpc.Push5XXResult(ctx, mainTaskID, responses5xx)
+		} else {
+			err = app.Producers[tName].PublicMessage(ctx, app.newTestMessage(tName, mainTaskID, responses, templates))
+		}
+		if err != nil {
+			failed("Error publishing task for\t%s:\t%v\n", tName, err)
+		}
+	}
+
+	if isRequested(tests, Topic_Diff) && app.Diff != nil {
+		if err := app.Producers[Topic_Diff].PublicMessage(ctx, newDiffMessage(mainTaskID, app.Diff)); err != nil {
+			failed("Error publishing task for\t%s:\t%v\n", Topic_Diff, err)
+		}
+	}
+
+	for _, tName := range tests {
+		findingType, ok := findingTypeOf(TestTopicName(tName))
+		if !ok {
+			continue
+		}
+		if err := app.ClientGrpc.PushFindings(ctx, mainTaskID, findingType, app.Crawler.Findings.ByType(findingType)); err != nil {
+			failed("Error pushing findings for\t%s:\t%v\n", TestTopicName(tName), err)
+		}
+	}
+
+	return result
+}
+
+//newTestMessage builds a message for a given test topic, forwarding only representatives of near-duplicate pages,
+//reflections are sent to XSS-check only
+func (app *Config) newTestMessage(topic TestTopicName, mainTaskID string, responses []*crawler.Response, templates []*crawler.EndpointTemplate) *model.MessageProduce {
+	representatives := EnvVarOfType("CRAWLER_GROUP_REPRESENTATIVES", TypeInt).(int)
+	maxDistance := EnvVarOfType("CRAWLER_GROUP_MAX_DISTANCE", TypeInt).(int)
+
+	urls := make([]string, 0, len(responses))
+	var groups []*model.URLGroup
+	if representatives <= 0 {
+		for _, resp := range responses {
+			urls = append(urls, resp.VisitedLink.URL)
+		}
+	} else {
+		for _, group := range crawler.GroupSimilar(responses, maxDistance) {
+			urls = append(urls, group.Representatives(representatives)...)
+			if len(group.URLs) > 1 {
+				groups = append(groups, &model.URLGroup{Pattern: group.Pattern, URLs: group.URLs})
+			}
+		}
+	}
+
+	message := model.NewMessageProduce(mainTaskID, urls)
+	message.Value.StopReason = app.Crawler.StopReason()
+	message.Value.HAR = app.HARFile
+	message.Value.Graph = app.GraphFile
+	message.Value.PreviousTask = app.Previous
+	message.Value.Groups = groups
+	message.Value.Templates = templatesForResponses(templates, responses)
+	message.Value.Features = make(map[string][]string, len(responses))
+	for _, resp := range responses {
+		message.Value.Features[resp.VisitedLink.URL] = resp.Features.Names()
+		if topic != Topic_XSS {
+			continue
+		}
+		for _, reflection := range resp.Reflections {
+			if message.Value.Reflections == nil {
+				message.Value.Reflections = map[string][]*model.Reflection{}
+			}
+			message.Value.Reflections[resp.VisitedLink.URL] = append(message.Value.Reflections[resp.VisitedLink.URL],
+				&model.Reflection{Param: reflection.Param, Contexts: reflection.Contexts})
+		}
+	}
+
+	return message
+}
+
+//templatesForResponses converts templates to [model.Template] keeping only urls of given responses
+func templatesForResponses(templates []*crawler.EndpointTemplate, responses []*crawler.Response) []*model.Template {
+	links := make(map[string]bool, len(responses))
+	for _, resp := range responses {
+		links[resp.VisitedLink.URL] = true
+	}
+
+	var result []*model.Template
+	for _, template := range templates {
+		var urls []string
+		for _, link := range template.URLs {
+			if links[link] {
+				urls = append(urls, link)
+			}
+		}
+		if len(urls) == 0 {
+			continue
+		}
+
+		modelTemplate := &model.Template{
+			Template:  template.Template,
+			SampleURL: urls[0],
+			URLs:      urls,
+		}
+		for _, param := range template.Params {
+			modelParam := &model.TemplateParam{
+				Name:    param.Name,
+				In:      param.In,
+				Type:    param.Type,
+				Samples: param.Samples,
+			}
+			if param.In == crawler.ParamInPath {
+				position := param.Position
+				modelParam.Position = &position //segment 0 is a valid position
+			}
+			modelTemplate.Params = append(modelTemplate.Params, modelParam)
+		}
+		result = append(result, modelTemplate)
+	}
+
+	return result
+}
+
+//newDiffMessage returns message for diff topic with urls of added & changed endpoints
+func newDiffMessage(mainTaskID string, diff *store.CrawlDiff) *model.MessageProduce {
+	modelDiff := &model.CrawlDiff{
+		From:      diff.From,
+		To:        diff.To,
+		Endpoints: make([]*model.EndpointDiff, 0, len(diff.Endpoints)),
+		Templates: make([]*model.TemplateDiff, 0, len(diff.Templates)),
+	}
+	var urls []string
+	for _, endpoint := range diff.Endpoints {
+		if endpoint.Kind != store.DiffRemoved {
+			urls = append(urls, endpoint.URL)
+		}
+		modelDiff.Endpoints = append(modelDiff.Endpoints, &model.EndpointDiff{
+			Kind:            endpoint.Kind,
+			URL:             endpoint.URL,
+			Template:        endpoint.Template,
+			StatusFrom:      endpoint.StatusFrom,
+			StatusTo:        endpoint.StatusTo,
+			AddedFeatures:   endpoint.AddedFeatures,
+			RemovedFeatures: endpoint.RemovedFeatures,
+			AddedForms:      endpoint.AddedForms,
+			RemovedForms:    endpoint.RemovedForms,
+		})
+	}
+	for _, template := range diff.Templates {
+		modelDiff.Templates = append(modelDiff.Templates, &model.TemplateDiff{
+			Kind:     template.Kind,
+			Template: template.Template,
+			URLs:     template.URLs,
+		})
+	}
+
+	message := model.NewMessageProduce(mainTaskID, urls)
+	message.Value.PreviousTask = diff.From
+	message.Value.Diff = modelDiff
+
+	return message
+}
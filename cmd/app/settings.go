@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"parabellum.crawler/internal/crawler"
+	"parabellum.crawler/internal/model"
+)
+
+//CrawlSettings effective crawl parameters of a single task: service defaults with task overrides, bounded by hard limits
+type CrawlSettings struct {
+	MaxDepth          int                   //max depth of visiting links
+	Budget            crawler.Budget        //resource limits of the crawl
+	Timeout           time.Duration         //crawl timeout
+	Threads           int                   //number of goroutines to crawl with
+	Redirects         int                   //max number of redirects to follow for a single request
+	ParseStatuses     crawler.StatusClasses //status classes of responses whose bodies are parsed
+	RateLimit         float64               //max requests per second, 0 - unlimited
+	Priority          crawler.Prioritizer   //order of visiting found links
+	Include           []*regexp.Regexp      //only urls matching any of them are visited
+	Exclude           []*regexp.Regexp      //urls matching any of them are skipped
+	Headers           http.Header           //headers to send with every request
+	IncludeSoft404    bool                  //forward soft-404 pages to tests
+	DetectReflections bool                  //find query parameters reflected in pages
+	RecordHAR         bool                  //record requests & responses into HAR file
+	SeedHAR           *crawler.HAR          //archive to seed the crawl from, nil - no seeding
+	ExportGraph       string                //format of site graph to export, empty - not recorded
+	Incremental       bool                  //compare pages with the previous crawl of the same url from the store
+	ChangedOnly       bool                  //forward only new or changed pages to tests
+}
+
+//NewCrawlSettings calculates [main.CrawlSettings] from service defaults and given task options
+func NewCrawlSettings(opts *model.CrawlOptions) (*CrawlSettings, error) {
+	if opts == nil {
+		opts = new(model.CrawlOptions)
+	}
+
+	settings := &CrawlSettings{
+		MaxDepth: boundedInt(opts.MaxDepth, "CRAWLER_MAX_DEPTH", "CRAWLER_LIMIT_MAX_DEPTH"),
+		Budget: crawler.Budget{
+			MaxPages:          boundedInt(opts.MaxPages, "CRAWLER_MAX_PAGES", "CRAWLER_LIMIT_MAX_PAGES"),
+			MaxTotalBytes:     int64(EnvVarOfType("CRAWLER_MAX_TOTAL_BYTES", TypeInt).(int)),
+			MaxBodyBytes:      int64(EnvVarOfType("CRAWLER_MAX_BODY_BYTES", TypeInt).(int)),
+			MaxURLsPerPattern: EnvVarOfType("CRAWLER_MAX_URLS_PER_PATTERN", TypeInt).(int),
+			MaxProbes:         EnvVarOfType("CRAWLER_MAX_PROBES", TypeInt).(int),
+			MaxCanaries:       EnvVarOfType("CRAWLER_MAX_CANARIES", TypeInt).(int),
+		},
+		Threads:           boundedInt(opts.Threads, "CRAWLER_NUM_OF_THREADS", "CRAWLER_LIMIT_NUM_OF_THREADS"),
+		Redirects:         EnvVarOfType("CRAWLER_MAX_REDIRECTS", TypeInt).(int),
+		RateLimit:         boundedFloat(opts.RateLimit, "CRAWLER_RATE_LIMIT", "CRAWLER_LIMIT_RATE"),
+		Headers:           http.Header{},
+		IncludeSoft404:    opts.IncludeSoft404,
+		DetectReflections: opts.DetectReflections || EnvVarOfType("CRAWLER_DETECT_REFLECTIONS", TypeInt).(int) > 0,
+		RecordHAR:         opts.RecordHAR || EnvVarOfType("CRAWLER_RECORD_HAR", TypeInt).(int) > 0,
+		ExportGraph:       opts.ExportGraph,
+		Incremental:       opts.Incremental,
+		ChangedOnly:       opts.Incremental && opts.ChangedOnly,
+	}
+	timeoutSec := boundedInt(opts.Timeout, "CRAWLER_DEFAULT_TIMEOUT", "CRAWLER_LIMIT_TIMEOUT")
+	settings.Timeout = time.Duration(timeoutSec) * time.Second
+
+	var err error
+	if settings.ParseStatuses, err = crawler.ParseStatusClasses(EnvVarOfType("CRAWLER_PARSE_STATUS_CLASSES", TypeString).(string)); err != nil {
+		return nil, err
+	}
+	if settings.Include, err = compilePatterns(opts.Include); err != nil {
+		return nil, err
+	}
+	if settings.Exclude, err = compilePatterns(opts.Exclude); err != nil {
+		return nil, err
+	}
+
+	priority := opts.Priority
+	if priority == "" {
+		priority = EnvVarOfType("CRAWLER_PRIORITY", TypeString).(string)
+	}
+	if settings.Priority, err = crawler.NewPrioritizer(priority); err != nil {
+		return nil, err
+	}
+
+	switch settings.ExportGraph {
+	case "", crawler.GraphFormatDOT, crawler.GraphFormatGraphML, crawler.GraphFormatJSON:
+	default:
+		return nil, fmt.Errorf("unknown graph format %q", settings.ExportGraph)
+	}
+	if opts.SeedHAR != "" {
+		harPath := filepath.Join(EnvVarOfType("CRAWLER_ARTIFACTS_DIR", TypeString).(string), filepath.Base(opts.SeedHAR))
+		if settings.SeedHAR, err = crawler.LoadHAR(harPath); err != nil {
+			return nil, err
+		}
+	}
+
+	for key, val := range opts.Headers {
+		settings.Headers.Set(key, val)
+	}
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = EnvVarOfType("CRAWLER_USER_AGENT", TypeString).(string)
+	}
+	settings.Headers.Set("User-Agent", userAgent)
+
+	return settings, nil
+}
+
+//Apply sets settings to a given crawler
+func (settings *CrawlSettings) Apply(cr *crawler.Crawler) {
+	cr.MaxJumps = settings.MaxDepth
+	cr.Budget = settings.Budget
+	cr.Include = settings.Include
+	cr.Exclude = settings.Exclude
+	cr.Headers = settings.Headers.Clone()
+	if settings.SeedHAR != nil {
+		for key, values := range settings.SeedHAR.SessionHeaders(cr.URL.Host) {
+			if cr.Headers.Get(key) == "" {
+				cr.Headers[key] = values
+			}
+		}
+	}
+	if settings.ExportGraph != "" {
+		cr.Graph = crawler.NewSiteGraph()
+	}
+	if settings.RecordHAR {
+		cr.Recorder = crawler.NewHARRecorder(
+			EnvVarOfType("CRAWLER_HAR_MAX_BODY_BYTES", TypeInt).(int),
+			EnvVarOfType("CRAWLER_HAR_MAX_TOTAL_BYTES", TypeInt).(int),
+		)
+	}
+	cr.MaxRedirects = settings.Redirects
+	cr.ParseStatuses = settings.ParseStatuses
+	cr.DetectSoft404 = EnvVarOfType("CRAWLER_DETECT_SOFT404", TypeInt).(int) > 0
+	cr.SetNumberOfThreads(settings.Threads)
+	cr.SetPrioritizer(settings.Priority)
+	cr.SetRateLimit(settings.RateLimit)
+}
+
+//boundedInt returns requested value (or env default if not requested) not greater than env hard limit
+func boundedInt(requested int, defaultEnv, limitEnv string) int {
+	result := requested
+	if result <= 0 {
+		result = EnvVarOfType(defaultEnv, TypeInt).(int)
+	}
+	if limit := EnvVarOfType(limitEnv, TypeInt).(int); limit > 0 && result > limit {
+		result = limit
+	}
+
+	return result
+}
+
+//boundedFloat returns requested value (or env default if not requested) not greater than env hard limit
+func boundedFloat(requested float64, defaultEnv, limitEnv string) float64 {
+	result := requested
+	if result <= 0 {
+		result = EnvVarOfType(defaultEnv, TypeFloat).(float64)
+	}
+	limit := EnvVarOfType(limitEnv, TypeFloat).(float64)
+	if limit > 0 && (result <= 0 || result > limit) {
+		result = limit
+	}
+
+	return result
+}
+
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	result := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		rgx, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("wrong url pattern %q: %w", pattern, err)
+		}
+		result = append(result, rgx)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,358 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"parabellum.crawler/internal/crawler"
+	"parabellum.crawler/internal/model"
+)
+
+//Kinds of crawl events
+const (
+	EventProgress = "progress" //a frontier link was handled by a replica
+	EventDone     = "done"     //coordinator completed the crawl, replicas drop its shards
+)
+
+//doneRetention time completed crawls are remembered for to skip their links left in the frontier
+const doneRetention = time.Hour
+
+//FrontierLink link of a task to be visited by the replica owning its shard, published with link url as a key
+type FrontierLink struct {
+	Task    *model.TaskConsume `json:"task"`
+	CrawlID string             `json:"crawlId"` //id of the crawl of the task, a redelivered task is crawled with a new id
+	Link    *crawler.Link      `json:"link"`
+}
+
+//Event crawl event of a task, published with task id as a key, so events of one task keep their order
+type Event struct {
+	Kind       string                  `json:"kind"`                 //EventProgress or EventDone
+	TaskID     string                  `json:"taskId"`               //id of the crawled task
+	CrawlID    string                  `json:"crawlId"`              //id of the crawl, see [cluster.FrontierLink]
+	Link       string                  `json:"link,omitempty"`       //url of the handled frontier link
+	Page       *crawler.CheckpointPage `json:"page,omitempty"`       //visited page, nil - link was skipped by its shard
+	Discovered []string                `json:"discovered,omitempty"` //urls of links published into the frontier from the page
+	Findings   []*crawler.Finding      `json:"findings,omitempty"`   //findings of passive checks on the page
+}
+
+//Publisher publishes values into a topic, implemented by [pubsub.Producer]
+type Publisher interface {
+	PublishValue(ctx context.Context, key string, value any) error
+}
+
+//Fetcher reads values from a topic, implemented by [pubsub.Consumer]
+type Fetcher interface {
+	FetchValue(ctx context.Context, value any) (*model.MessageConsume, error)
+	CommitMessage(ctx context.Context, msg *model.MessageConsume) error
+}
+
+//CrawlerFactory returns crawler of a task shard with task settings applied
+type CrawlerFactory func(ctx context.Context, task *model.TaskConsume) (*crawler.Crawler, error)
+
+//Node crawler replica of a distributed crawl: visits links of its shards & coordinates tasks it received from API
+type Node struct {
+	Frontier   Fetcher        //to read links of the node's shards
+	Links      Publisher      //to push found links into the frontier topic
+	Events     Publisher      //to push crawl events
+	Progress   Fetcher        //to read events of all nodes
+	Threads    int            //number of links visited concurrently
+	Replicas   int            //number of replicas sharing limits of a task, see [crawler.Crawler.ShareLimits]
+	NewCrawler CrawlerFactory //to create crawler of a task shard
+
+	mu     sync.Mutex
+	shards map[string]*crawler.Crawler //shard crawlers by crawl id, every one deduplicates links of its shard
+	failed map[string]error            //errors of shard crawlers creation by crawl id, links of such crawls are skipped
+	done   map[string]time.Time        //completion time of crawls by crawl id, their links are skipped
+	tasks  map[string]*coordination    //crawls coordinated by the node by crawl id
+}
+
+//coordination completion state of a task coordinated by the node
+type coordination struct {
+	cr *crawler.Crawler //aggregates pages of all shards
+	//number of publications of each link into the frontier not handled yet by url, negative if the link was handled
+	//before the event of the page it was found on, as events of different replicas are not ordered
+	pending  map[string]int
+	finished chan struct{}
+}
+
+//newCoordination is a [cluster.coordination] constructor expecting given links to be handled
+func newCoordination(cr *crawler.Crawler, links []*crawler.Link) *coordination {
+	result := &coordination{cr: cr, pending: map[string]int{}, finished: make(chan struct{})}
+	for _, l := range links {
+		result.count(l.URL, 1)
+	}
+
+	return result
+}
+
+//count adds a given number of publications of a link, the link is dropped from pending once its count is zero
+func (coord *coordination) count(link string, delta int) {
+	coord.pending[link] += delta
+	if coord.pending[link] == 0 {
+		delete(coord.pending, link)
+	}
+}
+
+//NewNode is a [cluster.Node] constructor
+func NewNode(frontier Fetcher, links, events Publisher, progress Fetcher, threads, replicas int, newCrawler CrawlerFactory) *Node {
+	return &Node{
+		Frontier:   frontier,
+		Links:      links,
+		Events:     events,
+		Progress:   progress,
+		Threads:    threads,
+		Replicas:   replicas,
+		NewCrawler: newCrawler,
+		shards:     map[string]*crawler.Crawler{},
+		failed:     map[string]error{},
+		done:       map[string]time.Time{},
+		tasks:      map[string]*coordination{},
+	}
+}
+
+//Run visits frontier links & handles crawl events until ctx is done: frontier links are fetched in one loop & visited
+//by node.Threads goroutines, they are committed in order of fetching, see [cluster.commitQueue]
+func (node *Node) Run(ctx context.Context) {
+	jobs := make(chan *frontierJob)
+	commits := newCommitQueue(node.Frontier)
+	var wg sync.WaitGroup
+	for i := 0; i < node.Threads; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				node.handleLink(ctx, commits, job)
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for node.nextLink(ctx, commits, jobs) {
+		}
+		close(jobs)
+	}()
+	for node.nextEvent(ctx) {
+	}
+	wg.Wait()
+}
+
+//Crawl publishes a given root link & seeds of the task into the frontier & waits until all found links are handled by replicas,
+//the budget of cr is exhausted or ctx is done, pages & findings of all shards are added into cr;
+//every call is a new crawl with its own shards, so a redelivered task doesn't reuse shards of the previous attempt
+func (node *Node) Crawl(ctx context.Context, task *model.TaskConsume, cr *crawler.Crawler, root *crawler.Link, seeds []*crawler.Link) error {
+	crawlID := uuid.NewString()
+	links := append([]*crawler.Link{root}, seeds...)
+	coord := newCoordination(cr, links)
+	node.mu.Lock()
+	node.tasks[crawlID] = coord
+	node.mu.Unlock()
+	defer node.finish(task.ID, crawlID)
+
+	for _, l := range links {
+		if err := node.Links.PublishValue(ctx, l.URL, &FrontierLink{Task: task, CrawlID: crawlID, Link: l}); err != nil {
+			return err
+		}
+	}
+
+	select {
+	case <-coord.finished:
+	case <-ctx.Done():
+	}
+
+	return nil
+}
+
+//finish stops coordinating a crawl & tells replicas to drop its shards
+func (node *Node) finish(taskID, crawlID string) {
+	node.mu.Lock()
+	delete(node.tasks, crawlID)
+	node.mu.Unlock()
+
+	//ctx of the task may be done already
+	if err := node.Events.PublishValue(context.Background(), taskID, &Event{Kind: EventDone, TaskID: taskID, CrawlID: crawlID}); err != nil {
+		log.Printf("Error publishing completion of task ID: %s\t%v\n", taskID, err)
+	}
+}
+
+//frontierJob fetched frontier link to visit, link is nil if the message was not read
+type frontierJob struct {
+	link    *FrontierLink
+	message *fetchedMessage
+}
+
+//nextLink fetches the next frontier link & hands it to visiting goroutines, returns false when ctx is done
+func (node *Node) nextLink(ctx context.Context, commits *commitQueue, jobs chan<- *frontierJob) bool {
+	frontierLink := new(FrontierLink)
+	msg, err := node.Frontier.FetchValue(ctx, frontierLink)
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		log.Printf("Error reading frontier link:\t%v\n", err)
+	}
+	if msg == nil {
+		return true
+	}
+
+	job := &frontierJob{message: commits.add(msg)}
+	if err == nil && frontierLink.Task != nil && frontierLink.Link != nil {
+		job.link = frontierLink
+	}
+	jobs <- job
+
+	return true
+}
+
+//handleLink visits a fetched frontier link & commits it, a link interrupted by ctx done is left uncommitted to be delivered again
+func (node *Node) handleLink(ctx context.Context, commits *commitQueue, job *frontierJob) {
+	if job.link != nil {
+		if err := node.VisitLink(ctx, job.link); err != nil {
+			log.Printf("Error handling link %s of task ID: %s\t%v\n", job.link.Link.URL, job.link.Task.ID, err)
+		}
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	if err := commits.done(ctx, job.message); err != nil {
+		log.Printf("Error committing frontier link:\t%v\n", err)
+	}
+}
+
+//VisitLink visits a frontier link with the shard crawler of its task, publishes found links & then progress event
+//with the links published successfully, so the coordinator doesn't wait for links lost on publishing
+func (node *Node) VisitLink(ctx context.Context, frontierLink *FrontierLink) error {
+	event := &Event{Kind: EventProgress, TaskID: frontierLink.Task.ID, CrawlID: frontierLink.CrawlID, Link: frontierLink.Link.URL}
+	shard, err := node.shard(ctx, frontierLink)
+	if err != nil {
+		//the link is reported as skipped, so the coordinator doesn't wait for it
+		if errPublish := node.Events.PublishValue(ctx, frontierLink.Task.ID, event); errPublish != nil {
+			log.Printf("Error publishing progress of task ID: %s\t%v\n", frontierLink.Task.ID, errPublish)
+		}
+
+		return err
+	}
+	if shard == nil {
+		return nil
+	}
+
+	resp, links := shard.VisitLink(frontierLink.Link)
+	var failed int
+	var lastErr error
+	for _, l := range links {
+		if err = node.Links.PublishValue(ctx, l.URL, &FrontierLink{Task: frontierLink.Task, CrawlID: frontierLink.CrawlID, Link: l}); err != nil {
+			failed, lastErr = failed+1, err
+
+			continue
+		}
+		event.Discovered = append(event.Discovered, l.URL)
+	}
+	if resp != nil {
+		event.Page = crawler.NewCheckpointPage(resp)
+		event.Findings = shard.Findings.ByURL(resp.VisitedLink.URL)
+	}
+	if err = node.Events.PublishValue(ctx, frontierLink.Task.ID, event); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("error publishing %d of %d found links: %w", failed, len(links), lastErr)
+	}
+
+	return nil
+}
+
+//shard returns crawler of the node's shard of the crawl of a given link, nil if the crawl is completed,
+//error of the shard crawler creation is returned for every link of the crawl without retrying;
+//limits of the task are shared between replicas, so the site gets no more requests than the task allows
+func (node *Node) shard(ctx context.Context, frontierLink *FrontierLink) (*crawler.Crawler, error) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	if _, ok := node.done[frontierLink.CrawlID]; ok {
+		return nil, nil
+	}
+	if err, ok := node.failed[frontierLink.CrawlID]; ok {
+		return nil, err
+	}
+	if shard, ok := node.shards[frontierLink.CrawlID]; ok {
+		return shard, nil
+	}
+
+	shard, err := node.NewCrawler(ctx, frontierLink.Task)
+	if err != nil {
+		err = fmt.Errorf("error creating shard crawler: %w", err)
+		node.failed[frontierLink.CrawlID] = err
+
+		return nil, err
+	}
+	shard.ShareLimits(node.Replicas)
+	node.shards[frontierLink.CrawlID] = shard
+
+	return shard, nil
+}
+
+//nextEvent handles the next crawl event, returns false when ctx is done
+func (node *Node) nextEvent(ctx context.Context) bool {
+	event := new(Event)
+	msg, err := node.Progress.FetchValue(ctx, event)
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		log.Printf("Error reading crawl event:\t%v\n", err)
+	} else {
+		node.HandleEvent(event)
+	}
+	if msg != nil {
+		if err = node.Progress.CommitMessage(ctx, msg); err != nil {
+			log.Printf("Error committing crawl event:\t%v\n", err)
+		}
+	}
+
+	return true
+}
+
+//HandleEvent counts progress of a crawl coordinated by the node or drops shards of a completed crawl
+func (node *Node) HandleEvent(event *Event) {
+	node.mu.Lock()
+	defer node.mu.Unlock()
+
+	if event.Kind == EventDone {
+		node.forgetDone(time.Now().Add(-doneRetention))
+		node.done[event.CrawlID] = time.Now()
+		delete(node.shards, event.CrawlID)
+		delete(node.failed, event.CrawlID)
+
+		return
+	}
+
+	coord, ok := node.tasks[event.CrawlID]
+	if !ok {
+		return
+	}
+	coord.count(event.Link, -1)
+	for _, l := range event.Discovered {
+		coord.count(l, 1)
+	}
+	if event.Page != nil && coord.cr.AddVisited(event.Page.Response()) {
+		for _, finding := range event.Findings {
+			coord.cr.Findings.Add(finding)
+		}
+	}
+	if len(coord.pending) == 0 || coord.cr.StopReason() != "" {
+		delete(node.tasks, event.CrawlID)
+		close(coord.finished)
+	}
+}
+
+//forgetDone drops crawls completed before a given time, links of so old crawls are not expected in the frontier
+func (node *Node) forgetDone(before time.Time) {
+	for crawlID, completed := range node.done {
+		if completed.Before(before) {
+			delete(node.done, crawlID)
+		}
+	}
+}
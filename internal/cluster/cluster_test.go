@@ -0,0 +1,383 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"parabellum.crawler/internal/crawler"
+	"parabellum.crawler/internal/model"
+)
+
+//memTopic in-memory topic, values are sent into the partition of the key hash or into every partition if broadcast
+type memTopic struct {
+	partitions []chan []byte
+	broadcast  bool
+}
+
+func newMemTopic(partitions int, broadcast bool) *memTopic {
+	result := &memTopic{broadcast: broadcast}
+	for i := 0; i < partitions; i++ {
+		result.partitions = append(result.partitions, make(chan []byte, 1000))
+	}
+
+	return result
+}
+
+func (topic *memTopic) PublishValue(ctx context.Context, key string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if topic.broadcast {
+		for _, partition := range topic.partitions {
+			partition <- data
+		}
+
+		return nil
+	}
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	topic.partitions[int(hash.Sum32())%len(topic.partitions)] <- data
+
+	return nil
+}
+
+type memReader struct {
+	partition chan []byte
+}
+
+func (reader *memReader) FetchValue(ctx context.Context, value any) (*model.MessageConsume, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case data := <-reader.partition:
+		return &model.MessageConsume{}, json.Unmarshal(data, value)
+	}
+}
+
+func (reader *memReader) CommitMessage(ctx context.Context, msg *model.MessageConsume) error {
+	return nil
+}
+
+//logReader in-memory partition log, offset of a message is kept in its Origin
+type logReader struct {
+	mu        sync.Mutex
+	values    [][]byte
+	next      int
+	committed int //offset of the last committed message, -1 if none
+}
+
+func newLogReader(values [][]byte) *logReader {
+	return &logReader{values: values, committed: -1}
+}
+
+func (reader *logReader) FetchValue(ctx context.Context, value any) (*model.MessageConsume, error) {
+	reader.mu.Lock()
+	if reader.next < len(reader.values) {
+		offset := reader.next
+		reader.next++
+		reader.mu.Unlock()
+
+		return &model.MessageConsume{Origin: offset}, json.Unmarshal(reader.values[offset], value)
+	}
+	reader.mu.Unlock()
+	<-ctx.Done()
+
+	return nil, ctx.Err()
+}
+
+func (reader *logReader) CommitMessage(ctx context.Context, msg *model.MessageConsume) error {
+	reader.mu.Lock()
+	defer reader.mu.Unlock()
+	if offset := msg.Origin.(int); offset > reader.committed {
+		reader.committed = offset
+	} else {
+		return fmt.Errorf("offset %d committed after %d", offset, reader.committed)
+	}
+
+	return nil
+}
+
+func (reader *logReader) lastCommitted() int {
+	reader.mu.Lock()
+	defer reader.mu.Unlock()
+
+	return reader.committed
+}
+
+func newTestSite(t *testing.T) (*httptest.Server, map[string]int) {
+	var mu sync.Mutex
+	requested := map[string]int{}
+	pages := map[string]string{
+		"/":  "<a href='/a'>a</a><a href='/b'>b</a>",
+		"/a": "<a href='/b'>b</a><a href='/c'>c</a>",
+		"/b": "<a href='/a'>a</a><a href='/c'>c</a><form></form>",
+		"/c": "<a href='/'>root</a>",
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested[r.URL.Path]++
+		mu.Unlock()
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, page)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, requested
+}
+
+//runNodes runs given number of nodes reading partitions of in-memory topics until the test ends
+func runNodes(t *testing.T, replicas int, factory CrawlerFactory) []*Node {
+	frontier, events := newMemTopic(replicas, false), newMemTopic(replicas, true)
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	nodes := make([]*Node, replicas)
+	for i := range nodes {
+		nodes[i] = NewNode(&memReader{frontier.partitions[i]}, frontier, events, &memReader{events.partitions[i]}, 2, replicas, factory)
+		wg.Add(1)
+		go func(node *Node) {
+			defer wg.Done()
+			node.Run(ctx)
+		}(nodes[i])
+	}
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+
+	return nodes
+}
+
+func TestNodeCrawl(t *testing.T) {
+	server, requested := newTestSite(t)
+	siteURL, _ := url.Parse(server.URL)
+	task := &model.TaskConsume{ID: "task-1", URL: server.URL}
+	nodes := runNodes(t, 3, func(ctx context.Context, task *model.TaskConsume) (*crawler.Crawler, error) {
+		taskURL, err := url.Parse(task.URL)
+		if err != nil {
+			return nil, err
+		}
+		shard := crawler.NewCrawler(ctx, taskURL)
+		shard.MaxJumps = 3
+
+		return shard, nil
+	})
+
+	aggregate := crawler.NewCrawler(context.Background(), siteURL)
+	crawlCtx, cancelCrawl := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCrawl()
+	require.NoError(t, nodes[0].Crawl(crawlCtx, task, aggregate, crawler.NewLink(server.URL+"/"), nil), "no error expected")
+	require.NoError(t, crawlCtx.Err(), "crawl should complete before timeout")
+
+	for _, path := range []string{"/", "/a", "/b", "/c"} {
+		value, ok := aggregate.Result.Load(server.URL + path)
+		require.True(t, ok, "page should be collected from its shard: %s", path)
+		require.Equal(t, http.StatusOK, value.(*crawler.Response).StatusCode, "should be equal")
+		require.Equal(t, 1, requested[path], "every page should be visited once by the replica owning it: %s", path)
+	}
+	value, _ := aggregate.Result.Load(server.URL + "/b")
+	require.True(t, value.(*crawler.Response).HasAnyFeature(crawler.FeatureForm), "should keep page features")
+}
+
+func TestNodeCrawlLimits(t *testing.T) {
+	var mu sync.Mutex
+	var requests []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, time.Now())
+		mu.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, r.URL.Path)
+		for i := 0; i < 30; i++ {
+			fmt.Fprintf(w, "<a href='/p/%d'>%d</a>", i, i)
+		}
+	}))
+	t.Cleanup(server.Close)
+	siteURL, _ := url.Parse(server.URL)
+	task := &model.TaskConsume{ID: "task-1", URL: server.URL}
+
+	replicas, maxPages, rateLimit := 3, 9, 10.0
+	nodes := runNodes(t, replicas, func(ctx context.Context, task *model.TaskConsume) (*crawler.Crawler, error) {
+		taskURL, err := url.Parse(task.URL)
+		if err != nil {
+			return nil, err
+		}
+		shard := crawler.NewCrawler(ctx, taskURL)
+		shard.MaxJumps = 3
+		shard.Budget.MaxPages = maxPages
+		shard.SetRateLimit(rateLimit)
+		shard.SetNumberOfThreads(2)
+
+		return shard, nil
+	})
+
+	aggregate := crawler.NewCrawler(context.Background(), siteURL)
+	aggregate.Budget.MaxPages = maxPages
+	crawlCtx, cancelCrawl := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCrawl()
+	require.NoError(t, nodes[0].Crawl(crawlCtx, task, aggregate, crawler.NewLink(server.URL+"/"), nil), "no error expected")
+	require.NoError(t, crawlCtx.Err(), "crawl should complete before timeout")
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.LessOrEqual(t, len(requests), maxPages, "replicas together should not exceed pages budget of the task")
+	//every replica may send its first request at once, the rest are spaced by the shared rate limit
+	minDuration := time.Duration(float64(len(requests)-replicas) / rateLimit * float64(time.Second))
+	require.GreaterOrEqual(t, requests[len(requests)-1].Sub(requests[0]), minDuration-50*time.Millisecond,
+		"replicas together should not exceed rate limit of the task")
+}
+
+func TestNodeRedelivery(t *testing.T) {
+	release := make(chan struct{})
+	var mu sync.Mutex
+	requested := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested[r.URL.Path]++
+		mu.Unlock()
+		if r.URL.Path == "/1" {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Header().Set("Content-Type", "text/html")
+	}))
+	t.Cleanup(server.Close)
+
+	task := &model.TaskConsume{ID: "task-1", URL: server.URL}
+	var values [][]byte
+	for i := 0; i < 5; i++ {
+		data, _ := json.Marshal(&FrontierLink{Task: task, CrawlID: "crawl-1", Link: crawler.NewLink(fmt.Sprintf("%s/%d", server.URL, i))})
+		values = append(values, data)
+	}
+	factory := func(ctx context.Context, task *model.TaskConsume) (*crawler.Crawler, error) {
+		taskURL, err := url.Parse(task.URL)
+		if err != nil {
+			return nil, err
+		}
+		shard := crawler.NewCrawler(ctx, taskURL)
+		shard.SetNumberOfThreads(3)
+
+		return shard, nil
+	}
+	runNode := func(ctx context.Context, reader *logReader) (*memTopic, func()) {
+		events := newMemTopic(1, true)
+		node := NewNode(reader, newMemTopic(1, false), events, &memReader{make(chan []byte)}, 3, 1, factory)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			node.Run(ctx)
+		}()
+
+		return events, func() { <-done }
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	reader := newLogReader(values)
+	events, wait := runNode(ctx, reader)
+	require.Eventually(t, func() bool { return len(events.partitions[0]) == 4 }, 5*time.Second, 10*time.Millisecond,
+		"links around the slow one should be handled")
+	require.Equal(t, 0, reader.lastCommitted(), "links after the unhandled one should not be committed")
+	cancel() //replica stops in the middle of the batch
+	close(release)
+	wait()
+	require.Equal(t, 0, reader.lastCommitted(), "interrupted link should not be committed")
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	redelivered := newLogReader(values[reader.lastCommitted()+1:])
+	events, wait = runNode(ctx, redelivered)
+	require.Eventually(t, func() bool { return len(events.partitions[0]) == 4 }, 5*time.Second, 10*time.Millisecond,
+		"links after the last committed one should be delivered again")
+	require.Eventually(t, func() bool { return redelivered.lastCommitted() == 3 }, 5*time.Second, 10*time.Millisecond,
+		"redelivered links should be committed")
+	cancel()
+	wait()
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, 1, requested["/0"], "committed link should not be delivered again")
+	require.Equal(t, 2, requested["/1"], "interrupted link should be delivered again")
+}
+
+func TestHandleEvent(t *testing.T) {
+	server, requested := newTestSite(t)
+	siteURL, _ := url.Parse(server.URL)
+	task := &model.TaskConsume{ID: "task-1", URL: server.URL}
+	frontier, events := newMemTopic(1, false), newMemTopic(1, true)
+	node := NewNode(nil, frontier, events, nil, 1, 1, func(ctx context.Context, task *model.TaskConsume) (*crawler.Crawler, error) {
+		return crawler.NewCrawler(ctx, siteURL), nil
+	})
+
+	root, a, b := server.URL+"/", server.URL+"/a", server.URL+"/b"
+	coord := newCoordination(crawler.NewCrawler(context.Background(), siteURL), []*crawler.Link{crawler.NewLink(root)})
+	node.tasks["crawl-1"] = coord
+	node.HandleEvent(&Event{Kind: EventProgress, TaskID: task.ID, CrawlID: "crawl-1", Link: a})
+	node.HandleEvent(&Event{Kind: EventProgress, TaskID: task.ID, CrawlID: "crawl-0", Link: root})
+	page := &crawler.CheckpointPage{Link: crawler.NewLink(root), StatusCode: http.StatusOK}
+	node.HandleEvent(&Event{Kind: EventProgress, TaskID: task.ID, CrawlID: "crawl-1", Link: root, Page: page, Discovered: []string{a, b}})
+	require.Equal(t, map[string]int{b: 1}, coord.pending, "found links should be expected, even if handled before the event of their page")
+	select {
+	case <-coord.finished:
+		t.Fatal("task should not be finished")
+	default:
+	}
+	node.HandleEvent(&Event{Kind: EventProgress, TaskID: task.ID, CrawlID: "crawl-1", Link: b})
+	<-coord.finished
+	_, ok := coord.cr.Result.Load(server.URL + "/")
+	require.True(t, ok, "visited page should be added")
+
+	require.NoError(t, node.VisitLink(context.Background(), &FrontierLink{Task: task, CrawlID: "crawl-1", Link: crawler.NewLink(server.URL + "/")}),
+		"no error expected")
+	require.Len(t, node.shards, 1, "shard crawler should be created")
+	node.HandleEvent(&Event{Kind: EventDone, TaskID: task.ID, CrawlID: "crawl-1"})
+	require.Empty(t, node.shards, "shard of completed crawl should be dropped")
+	require.NoError(t, node.VisitLink(context.Background(), &FrontierLink{Task: task, CrawlID: "crawl-1", Link: crawler.NewLink(server.URL + "/a")}),
+		"no error expected")
+	require.Zero(t, requested["/a"], "links of completed crawl should be skipped")
+
+	require.NoError(t, node.VisitLink(context.Background(), &FrontierLink{Task: task, CrawlID: "crawl-2", Link: crawler.NewLink(server.URL + "/")}),
+		"no error expected")
+	require.Equal(t, 2, requested["/"], "redelivered task should be crawled again with a new shard")
+
+	node.done["crawl-0"] = time.Now().Add(-2 * doneRetention)
+	node.HandleEvent(&Event{Kind: EventDone, TaskID: task.ID, CrawlID: "crawl-2"})
+	require.NotContains(t, node.done, "crawl-0", "old completed crawls should be forgotten")
+	require.Contains(t, node.done, "crawl-1", "recently completed crawls should be remembered")
+}
+
+func TestShardCrawlerError(t *testing.T) {
+	task := &model.TaskConsume{ID: "task-1", URL: "http://site/"}
+	frontier, events := newMemTopic(1, false), newMemTopic(1, true)
+	var created int
+	node := NewNode(nil, frontier, events, nil, 1, 1, func(ctx context.Context, task *model.TaskConsume) (*crawler.Crawler, error) {
+		created++
+
+		return nil, fmt.Errorf("no settings")
+	})
+
+	for _, link := range []string{"http://site/", "http://site/a"} {
+		require.Error(t, node.VisitLink(context.Background(), &FrontierLink{Task: task, CrawlID: "crawl-1", Link: crawler.NewLink(link)}),
+			"error expected")
+		event := new(Event)
+		require.NoError(t, json.Unmarshal(<-events.partitions[0], event), "no error expected")
+		require.Equal(t, link, event.Link, "link should be reported as skipped")
+	}
+	require.Equal(t, 1, created, "shard crawler creation should not be retried for every link")
+}
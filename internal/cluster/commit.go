@@ -0,0 +1,59 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+
+	"parabellum.crawler/internal/model"
+)
+
+//fetchedMessage message fetched from a partition & not committed yet
+type fetchedMessage struct {
+	msg     *model.MessageConsume
+	handled bool
+}
+
+//commitQueue commits messages handled concurrently in order of fetching within their partitions,
+//so a message is committed only when all messages fetched before it from its partition are handled,
+//messages not handled because of a crash are delivered again
+type commitQueue struct {
+	fetcher Fetcher
+	mu      sync.Mutex
+	fetched map[int][]*fetchedMessage //not committed messages by partition in order of fetching
+}
+
+//newCommitQueue is a [cluster.commitQueue] constructor
+func newCommitQueue(fetcher Fetcher) *commitQueue {
+	return &commitQueue{fetcher: fetcher, fetched: map[int][]*fetchedMessage{}}
+}
+
+//add puts a fetched message into the queue, should be called in order of fetching
+func (queue *commitQueue) add(msg *model.MessageConsume) *fetchedMessage {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	result := &fetchedMessage{msg: msg}
+	queue.fetched[msg.Partition] = append(queue.fetched[msg.Partition], result)
+
+	return result
+}
+
+//done marks a message as handled & commits the latest message of its partition all previous messages of which are handled
+func (queue *commitQueue) done(ctx context.Context, message *fetchedMessage) error {
+	queue.mu.Lock()
+	defer queue.mu.Unlock()
+
+	message.handled = true
+	partition := queue.fetched[message.msg.Partition]
+	var last *model.MessageConsume
+	for len(partition) > 0 && partition[0].handled {
+		last, partition = partition[0].msg, partition[1:]
+	}
+	queue.fetched[message.msg.Partition] = partition
+	if last == nil {
+		return nil
+	}
+
+	//commits are made under the lock, so an earlier message is never committed after a later one
+	return queue.fetcher.CommitMessage(ctx, last)
+}
@@ -0,0 +1,136 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//Budget reasons to stop the crawl reported by [crawler.Crawler.StopReason]
+const (
+	StopReasonMaxPages      = "max-pages"       //max number of pages was requested
+	StopReasonMaxTotalBytes = "max-total-bytes" //max number of body bytes was read
+	StopReasonTimeout       = "timeout"         //crawler context deadline was exceeded
+)
+
+//Budget resource limits for a single crawl, zero values mean "unlimited"
+type Budget struct {
+	MaxPages          int   //max number of pages to request
+	MaxTotalBytes     int64 //max number of response body bytes to read during the crawl
+	MaxBodyBytes      int64 //max number of bytes to read from a single response body, the rest is truncated
+	MaxURLsPerPattern int   //max number of urls to visit per url pattern, see [crawler.URLPattern]
+	MaxProbes         int   //max number of requests of the probe phase, see [crawler.Crawler.Probe]
+	MaxCanaries       int   //max number of requests of the reflection phase, see [crawler.Crawler.DetectReflections]
+}
+
+//budgetUsage counters of resources spent by the crawler
+type budgetUsage struct {
+	pages      int64
+	bytes      int64
+	patterns   sync.Map //url pattern -> *int64 number of visited urls
+	stopReason atomic.Value
+}
+
+//StopReason returns the name of the budget that stopped the crawl or empty string if it was completed
+func (cr *Crawler) StopReason() string {
+	if reason, ok := cr.usage.stopReason.Load().(string); ok {
+		return reason
+	}
+	if cr.ctx != nil && cr.ctx.Err() == context.DeadlineExceeded {
+		return StopReasonTimeout
+	}
+
+	return ""
+}
+
+//ShareLimits divides pages & total bytes budget, rate limit & number of threads of the crawler between a given number
+//of crawlers of one task, e.g. replicas of distributed crawl, so together they stay within limits of the task;
+//every crawler keeps at least one page & one thread, should be called before crawling
+func (cr *Crawler) ShareLimits(parts int) {
+	if parts <= 1 {
+		return
+	}
+
+	cr.Budget.MaxPages = shareOf(cr.Budget.MaxPages, parts)
+	cr.Budget.MaxTotalBytes = int64(shareOf(int(cr.Budget.MaxTotalBytes), parts))
+	cr.threads = shareOf(cr.threads, parts)
+	if cr.limiter != nil {
+		cr.limiter.interval *= time.Duration(parts)
+	}
+}
+
+//shareOf returns a part of a given limit, 0 - unlimited stays unlimited
+func shareOf(limit, parts int) int {
+	if limit <= 0 {
+		return limit
+	}
+	if limit < parts {
+		return 1
+	}
+
+	return limit / parts
+}
+
+func (cr *Crawler) stopOnBudget(reason string) {
+	if cr.usage.stopReason.CompareAndSwap(nil, reason) {
+		log.Printf("Crawling on %s stopped:\t%s budget exhausted\n", cr.URL, reason)
+	}
+}
+
+func (cr *Crawler) isBudgetExhausted() bool {
+	return cr.usage.stopReason.Load() != nil
+}
+
+//takePage reserves one page of the budget, returns false if no pages left
+func (cr *Crawler) takePage() bool {
+	pagesTaken := atomic.AddInt64(&cr.usage.pages, 1)
+	if cr.Budget.MaxPages <= 0 || pagesTaken <= int64(cr.Budget.MaxPages) {
+		return true
+	}
+	cr.stopOnBudget(StopReasonMaxPages)
+
+	return false
+}
+
+//takePatternSlot reserves one url of the budget for the link's pattern, returns false if the pattern is exhausted
+func (cr *Crawler) takePatternSlot(link string) bool {
+	if cr.Budget.MaxURLsPerPattern <= 0 {
+		return true
+	}
+
+	pattern := URLPattern(link)
+	counter, _ := cr.usage.patterns.LoadOrStore(pattern, new(int64))
+	taken := atomic.AddInt64(counter.(*int64), 1)
+	if taken == int64(cr.Budget.MaxURLsPerPattern)+1 {
+		log.Printf("Url pattern budget exhausted, skipping urls like:\t%s\n", pattern)
+	}
+
+	return taken <= int64(cr.Budget.MaxURLsPerPattern)
+}
+
+//limitBody wraps given body to respect max body size and to count read bytes into total bytes budget
+func (cr *Crawler) limitBody(body io.Reader) io.Reader {
+	if cr.Budget.MaxBodyBytes > 0 {
+		body = io.LimitReader(body, cr.Budget.MaxBodyBytes)
+	}
+
+	return &countingReader{reader: body, crawler: cr}
+}
+
+type countingReader struct {
+	reader  io.Reader
+	crawler *Crawler
+}
+
+func (cnt *countingReader) Read(p []byte) (int, error) {
+	n, err := cnt.reader.Read(p)
+	total := atomic.AddInt64(&cnt.crawler.usage.bytes, int64(n))
+	if maxBytes := cnt.crawler.Budget.MaxTotalBytes; maxBytes > 0 && total >= maxBytes {
+		cnt.crawler.stopOnBudget(StopReasonMaxTotalBytes)
+	}
+
+	return n, err
+}
@@ -0,0 +1,70 @@
+package crawler
+
+import (
+	"context"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTakePage(t *testing.T) {
+	crawler := NewCrawler(context.Background(), &url.URL{})
+	crawler.Budget.MaxPages = 2
+	require.True(t, crawler.takePage(), "first page should be taken")
+	require.True(t, crawler.takePage(), "second page should be taken")
+	require.False(t, crawler.takePage(), "third page should exceed the budget")
+	require.Equal(t, StopReasonMaxPages, crawler.StopReason(), "should report pages budget")
+	require.True(t, crawler.shouldExit(), "should exit on exhausted budget")
+}
+
+func TestTakePatternSlot(t *testing.T) {
+	crawler := NewCrawler(context.Background(), &url.URL{})
+	crawler.Budget.MaxURLsPerPattern = 1
+	require.True(t, crawler.takePatternSlot(fakeLink+"day/1"), "first url of pattern should be taken")
+	require.False(t, crawler.takePatternSlot(fakeLink+"day/2"), "second url of pattern should exceed the budget")
+	require.True(t, crawler.takePatternSlot(fakeLink+"month/1"), "url of another pattern should be taken")
+	require.Equal(t, "", crawler.StopReason(), "pattern budget should not stop the crawl")
+}
+
+func TestShareLimits(t *testing.T) {
+	crawler := NewCrawler(context.Background(), &url.URL{})
+	crawler.Budget = Budget{MaxPages: 10, MaxTotalBytes: 2, MaxBodyBytes: 100}
+	crawler.SetNumberOfThreads(7)
+	crawler.SetRateLimit(10)
+	crawler.ShareLimits(3)
+	require.Equal(t, Budget{MaxPages: 3, MaxTotalBytes: 1, MaxBodyBytes: 100}, crawler.Budget, "should divide budget keeping at least one unit")
+	require.Equal(t, 2, crawler.threads, "should divide threads")
+	require.Equal(t, 300*time.Millisecond, crawler.limiter.interval, "should divide rate limit")
+
+	crawler = NewCrawler(context.Background(), &url.URL{})
+	crawler.ShareLimits(3)
+	require.Zero(t, crawler.Budget.MaxPages, "unlimited budget should stay unlimited")
+	require.Nil(t, crawler.limiter, "unlimited rate should stay unlimited")
+}
+
+func TestLimitBody(t *testing.T) {
+	crawler := NewCrawler(context.Background(), &url.URL{})
+	crawler.Budget.MaxBodyBytes = 4
+	crawler.Budget.MaxTotalBytes = 6
+
+	read, err := ioutil.ReadAll(crawler.limitBody(strings.NewReader("0123456789")))
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, "0123", string(read), "body should be truncated")
+	require.Equal(t, "", crawler.StopReason(), "total budget is not exhausted yet")
+
+	_, _ = ioutil.ReadAll(crawler.limitBody(strings.NewReader("0123456789")))
+	require.Equal(t, StopReasonMaxTotalBytes, crawler.StopReason(), "should report total bytes budget")
+}
+
+func TestStopReasonTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	crawler := NewCrawler(ctx, &url.URL{})
+	require.Equal(t, StopReasonTimeout, crawler.StopReason(), "should report timeout")
+}
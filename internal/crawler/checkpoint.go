@@ -0,0 +1,204 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+//Checkpoint state of an unfinished crawl to resume it after restart, see [crawler.Crawler.Resume]
+type Checkpoint struct {
+	URL      string            `json:"url"`      //crawled url
+	Saved    time.Time         `json:"saved"`    //time the checkpoint was taken
+	Pages    []*CheckpointPage `json:"pages"`    //visited pages sorted by url
+	Frontier []*Link           `json:"frontier"` //links found but not visited yet sorted by url
+	Nodes    []*GraphNode      `json:"nodes,omitempty"`
+	Edges    []*Edge           `json:"edges,omitempty"`
+	Findings []*Finding        `json:"findings,omitempty"`
+	Usage    CheckpointUsage   `json:"usage"`
+}
+
+//CheckpointPage visited page without body
+type CheckpointPage struct {
+	Link        *Link       `json:"link"`
+	StatusCode  int         `json:"status"`
+	Header      http.Header `json:"header,omitempty"`
+	ContentType string      `json:"contentType,omitempty"`
+	Features    FeatureSet  `json:"features,omitempty"`
+	Forms       []*Form     `json:"forms,omitempty"`
+	Fingerprint uint64      `json:"fingerprint,omitempty"`
+	Soft404     bool        `json:"soft404,omitempty"`
+	Redirects   []*Redirect `json:"redirects,omitempty"`
+	Digest      string      `json:"digest,omitempty"`
+	Change      Change      `json:"change,omitempty"`
+}
+
+//CheckpointUsage budget spent before the checkpoint
+type CheckpointUsage struct {
+	Pages int64 `json:"pages"` //number of requested pages
+	Bytes int64 `json:"bytes"` //number of read body bytes
+}
+
+//Checkpoint returns current state of the crawl, safe to call while crawling
+func (cr *Crawler) Checkpoint() *Checkpoint {
+	result := &Checkpoint{URL: cr.URL.String(), Saved: time.Now()}
+
+	//frontier is taken before pages, so a page finished in between is both visited & in the frontier, and is requested again on resume
+	result.Frontier = cr.frontier.Links()
+
+	cr.Result.Range(func(key, value any) bool {
+		if resp, ok := value.(*Response); ok && resp.VisitedLink != nil {
+			result.Pages = append(result.Pages, NewCheckpointPage(resp))
+		}
+
+		return true
+	})
+	sort.Slice(result.Frontier, func(i, j int) bool { return result.Frontier[i].URL < result.Frontier[j].URL })
+	sort.Slice(result.Pages, func(i, j int) bool { return result.Pages[i].Link.URL < result.Pages[j].Link.URL })
+
+	if cr.Graph != nil {
+		result.Nodes, result.Edges = cr.Graph.Nodes(), cr.Graph.Edges()
+	}
+	if cr.Findings != nil {
+		result.Findings = cr.Findings.All()
+	}
+	result.Usage.Pages = atomic.LoadInt64(&cr.usage.pages)
+	result.Usage.Bytes = atomic.LoadInt64(&cr.usage.bytes)
+
+	return result
+}
+
+//NewCheckpointPage copies fields of a stored response, body fields are not read as they're cleared concurrently
+func NewCheckpointPage(resp *Response) *CheckpointPage {
+	return &CheckpointPage{
+		Link:        resp.VisitedLink,
+		StatusCode:  resp.StatusCode,
+		Header:      resp.Header,
+		ContentType: resp.ContentType,
+		Features:    resp.Features,
+		Forms:       resp.Forms,
+		Fingerprint: resp.Fingerprint,
+		Soft404:     resp.Soft404,
+		Redirects:   resp.Redirects,
+		Digest:      resp.Digest,
+		Change:      resp.Change,
+	}
+}
+
+//Response converts the page into [crawler.Response] without body
+func (page *CheckpointPage) Response() *Response {
+	return &Response{
+		VisitedLink: page.Link,
+		StatusCode:  page.StatusCode,
+		Header:      page.Header,
+		ContentType: page.ContentType,
+		Features:    page.Features,
+		Forms:       page.Forms,
+		Fingerprint: page.Fingerprint,
+		Soft404:     page.Soft404,
+		Redirects:   page.Redirects,
+		Digest:      page.Digest,
+		Change:      page.Change,
+	}
+}
+
+//Resume restores pages, findings, site graph & budget usage of a given checkpoint & visits its frontier,
+//should be called before [crawler.Crawler.ExploreLink] of the crawl url
+func (cr *Crawler) Resume(cp *Checkpoint) {
+	inFrontier := make(map[string]bool, len(cp.Frontier))
+	for _, l := range cp.Frontier {
+		inFrontier[l.URL] = true
+	}
+	for _, page := range cp.Pages {
+		if !inFrontier[page.Link.URL] {
+			cr.Result.Store(page.Link.URL, page.Response())
+		}
+	}
+	for _, node := range cp.Nodes {
+		cr.Graph.AddNode(node.URL, node.Status)
+	}
+	for _, edge := range cp.Edges {
+		cr.Graph.AddEdge(edge.From, edge.To, edge.Extractor)
+	}
+	for _, finding := range cp.Findings {
+		if cr.Findings != nil {
+			cr.Findings.Add(finding)
+		}
+	}
+	atomic.StoreInt64(&cr.usage.pages, cp.Usage.Pages)
+	atomic.StoreInt64(&cr.usage.bytes, cp.Usage.Bytes)
+	log.Printf("Resuming crawl on %s from checkpoint of %s:\t%d pages visited, %d in frontier\n",
+		cp.URL, cp.Saved.Format(time.RFC3339), len(cp.Pages)-len(inFrontier), len(cp.Frontier))
+
+	cr.enqueue(cp.Frontier...)
+}
+
+//KeepCheckpoint writes checkpoint of the crawl into a given file every interval until [crawler.Crawler.Wait] returns
+func (cr *Crawler) KeepCheckpoint(path string, interval time.Duration) {
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	cr.stopCheckpoint = func() {
+		close(stop)
+		<-stopped
+	}
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := cr.Checkpoint().Write(path); err != nil {
+					log.Printf("Error writing checkpoint of %s:\t%v\n", cr.URL, err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+//Write writes checkpoint into a given file atomically, creating its directory if needed
+func (cp *Checkpoint) Write(path string) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("error marshalling checkpoint: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating checkpoint directory: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err = os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+	if err = os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("error writing checkpoint: %w", err)
+	}
+
+	return nil
+}
+
+//LoadCheckpoint reads checkpoint from a given file, returns nil without error if there is no such file
+func LoadCheckpoint(path string) (*Checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading checkpoint: %w", err)
+	}
+
+	result := new(Checkpoint)
+	if err = json.Unmarshal(data, result); err != nil {
+		return nil, fmt.Errorf("error parsing checkpoint %s: %w", path, err)
+	}
+
+	return result, nil
+}
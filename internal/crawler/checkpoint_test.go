@@ -0,0 +1,113 @@
+package crawler
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type httpClientInterruptStub struct {
+	mu        sync.Mutex
+	pages     map[string]string
+	requested []string
+	interrupt string //link to cancel the crawl on
+	cancel    context.CancelFunc
+}
+
+func (hCl *httpClientInterruptStub) Do(req *http.Request) (*http.Response, error) {
+	link := req.URL.String()
+	hCl.mu.Lock()
+	hCl.requested = append(hCl.requested, link)
+	hCl.mu.Unlock()
+	if link == hCl.interrupt {
+		hCl.cancel()
+
+		return nil, errors.New("connection reset")
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader(hCl.pages[link])),
+	}, nil
+}
+
+func TestCheckpointResume(t *testing.T) {
+	urlFake, _ := url.Parse(fakeLink)
+	pages := map[string]string{
+		fakeLink:       "<a href='/a'>a</a><a href='/b'>b</a>",
+		fakeLink + "a": "<a href='/c'>c</a><form></form>",
+		fakeLink + "b": "<a href='/d'>d</a>",
+		fakeLink + "c": "c",
+		fakeLink + "d": "d",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupted := NewCrawler(ctx, urlFake)
+	interrupted.MaxJumps = 3
+	interrupted.Graph = NewSiteGraph()
+	interrupted.client = &httpClientInterruptStub{pages: pages, interrupt: fakeLink + "b", cancel: cancel}
+	interrupted.ExploreLink(NewLink(fakeLink))
+	time.Sleep(10 * time.Millisecond)
+	interrupted.Wait()
+
+	cpPath := filepath.Join(t.TempDir(), "checkpoints", "task-1.json")
+	require.NoError(t, interrupted.Checkpoint().Write(cpPath), "no error expected")
+	cp, err := LoadCheckpoint(cpPath)
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, fakeLink, cp.URL, "should keep crawled url")
+
+	frontier := map[string]bool{}
+	for _, l := range cp.Frontier {
+		frontier[l.URL] = true
+	}
+	require.True(t, frontier[fakeLink+"b"], "interrupted link should stay in the frontier")
+	visited := map[string]bool{}
+	for _, page := range cp.Pages {
+		if !frontier[page.Link.URL] {
+			visited[page.Link.URL] = true
+		}
+	}
+	require.True(t, visited[fakeLink], "root should be visited")
+	for _, link := range []string{fakeLink + "a", fakeLink + "c"} {
+		require.True(t, visited[link] || frontier[link], "every found page should be visited or in the frontier: %s", link)
+	}
+
+	resumed := NewCrawler(context.Background(), urlFake)
+	resumed.MaxJumps = 3
+	resumed.Graph = NewSiteGraph()
+	client := &httpClientInterruptStub{pages: pages, cancel: func() {}}
+	resumed.client = client
+	resumed.Resume(cp)
+	resumed.ExploreLink(NewLink(fakeLink))
+	time.Sleep(10 * time.Millisecond)
+	resumed.Wait()
+
+	for _, link := range client.requested {
+		require.False(t, visited[link], "pages visited before the checkpoint should not be requested again: %s", link)
+	}
+	for link := range pages {
+		value, ok := resumed.Result.Load(link)
+		require.True(t, ok, "resumed crawl should complete: %s", link)
+		require.Equal(t, http.StatusOK, value.(*Response).StatusCode, "should be equal")
+	}
+	value, _ := resumed.Result.Load(fakeLink + "a")
+	require.True(t, value.(*Response).HasAnyFeature(FeatureForm), "should restore features of visited pages")
+	require.Contains(t, resumed.Graph.Edges(), &Edge{From: fakeLink, To: fakeLink + "a", Extractor: "a[href]"}, "should restore site graph")
+	require.Empty(t, resumed.Checkpoint().Frontier, "frontier of completed crawl should be empty")
+}
+
+func TestLoadCheckpointMissing(t *testing.T) {
+	cp, err := LoadCheckpoint(filepath.Join(t.TempDir(), "task-1.json"))
+	require.NoError(t, err, "missing checkpoint is not an error")
+	require.Nil(t, cp, "should be nil")
+}
@@ -0,0 +1,91 @@
+package crawler
+
+import (
+	"bufio"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+//sniffLen number of body bytes used to detect content type, see [http.DetectContentType]
+const sniffLen = 512
+
+//DefaultParseStatusClasses status classes whose bodies are parsed by default: 2xx, 4xx & 5xx
+var DefaultParseStatusClasses = StatusClasses{2, 4, 5}
+
+//StatusClasses http status classes (first digit of the status code), e.g. {2, 4} for 2xx & 4xx
+type StatusClasses []int
+
+//ParseStatusClasses parses comma separated status classes, e.g. "2xx,4xx,5xx"
+func ParseStatusClasses(value string) (StatusClasses, error) {
+	var result StatusClasses
+	for _, class := range strings.Split(value, ",") {
+		class = strings.ToLower(strings.TrimSpace(class))
+		if class == "" {
+			continue
+		}
+		if len(class) != 3 || class[0] < '1' || class[0] > '5' || class[1:] != "xx" {
+			return nil, fmt.Errorf("wrong status class %q, expected one of 1xx-5xx", class)
+		}
+		result = append(result, int(class[0]-'0'))
+	}
+
+	return result, nil
+}
+
+//Includes returns true if a given status code belongs to one of the classes
+func (classes StatusClasses) Includes(statusCode int) bool {
+	for _, class := range classes {
+		if statusCode/100 == class {
+			return true
+		}
+	}
+
+	return false
+}
+
+//mediaTypeOf returns lower case media type of a given Content-Type header value without parameters
+func mediaTypeOf(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+
+	return mediaType
+}
+
+//IsTextContentType returns true for media types worth parsing for links & passive checks
+func IsTextContentType(mediaType string) bool {
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+xml"),
+		strings.HasSuffix(mediaType, "+json"),
+		strings.Contains(mediaType, "javascript"),
+		strings.Contains(mediaType, "ecmascript"):
+		return true
+	}
+
+	switch mediaType {
+	case "application/json", "application/xml", "application/x-www-form-urlencoded", "application/graphql":
+		return true
+	default:
+		return false
+	}
+}
+
+//readBody sniffs resp.ContentType if it's missing or generic & parses the body unless it is binary,
+//only a sniff-sized head of a binary body is read into resp.RawBody, e.g. for signatures of [crawler.Crawler.Probe]
+func (resp *Response) readBody(body *bufio.Reader) error {
+	head, _ := body.Peek(sniffLen)
+	if resp.ContentType == "" || resp.ContentType == "application/octet-stream" {
+		resp.ContentType = mediaTypeOf(http.DetectContentType(head))
+	}
+	if !IsTextContentType(resp.ContentType) {
+		resp.RawBody = append([]byte(nil), head...)
+
+		return nil
+	}
+
+	return resp.FillResponseBody(body)
+}
@@ -0,0 +1,113 @@
+package crawler
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStatusClasses(t *testing.T) {
+	classes, err := ParseStatusClasses("2xx, 4XX,5xx,")
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, StatusClasses{2, 4, 5}, classes, "should be equal")
+	require.True(t, classes.Includes(http.StatusPartialContent), "should include 206")
+	require.False(t, classes.Includes(http.StatusFound), "should not include 302")
+
+	_, err = ParseStatusClasses("2xx,600")
+	require.Error(t, err, "should fail on wrong class")
+}
+
+func TestNewResponseOf(t *testing.T) {
+	pngBody := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+	zipBody := "PK\x03\x04" + strings.Repeat("\x00", 2*sniffLen)
+
+	tabTests := []struct {
+		name                string
+		status              int
+		contentType         string
+		body                string
+		expectedContentType string
+		expectedParsed      bool
+		expectedRawBody     string
+	}{
+		{
+			name:                "created html page",
+			status:              http.StatusCreated,
+			contentType:         "text/html; charset=utf-8",
+			body:                "<html><a href='/'>home</a></html>",
+			expectedContentType: "text/html",
+			expectedParsed:      true,
+			expectedRawBody:     "<html><a href='/'>home</a></html>",
+		},
+		{
+			name:                "not found page with links",
+			status:              http.StatusNotFound,
+			body:                "<html><a href='/'>home</a></html>",
+			expectedContentType: "text/html",
+			expectedParsed:      true,
+			expectedRawBody:     "<html><a href='/'>home</a></html>",
+		},
+		{
+			name:                "redirect is not parsed",
+			status:              http.StatusNotModified,
+			contentType:         "text/html",
+			body:                "<html></html>",
+			expectedContentType: "text/html",
+			expectedParsed:      false,
+			expectedRawBody:     "",
+		},
+		{
+			name:                "pdf by header",
+			status:              http.StatusOK,
+			contentType:         "application/pdf",
+			body:                "%PDF-1.4",
+			expectedContentType: "application/pdf",
+			expectedParsed:      false,
+			expectedRawBody:     "%PDF-1.4",
+		},
+		{
+			name:                "image by sniffing",
+			status:              http.StatusOK,
+			contentType:         "application/octet-stream",
+			body:                pngBody,
+			expectedContentType: "image/png",
+			expectedParsed:      false,
+			expectedRawBody:     pngBody,
+		},
+		{
+			name:                "head of large archive",
+			status:              http.StatusOK,
+			contentType:         "application/zip",
+			body:                zipBody,
+			expectedContentType: "application/zip",
+			expectedParsed:      false,
+			expectedRawBody:     zipBody[:sniffLen],
+		},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.name, func(t *testing.T) {
+			urlFake, _ := url.Parse(fakeLink)
+			crawler := NewCrawler(context.Background(), urlFake)
+			resp := &http.Response{
+				StatusCode: test.status,
+				Header:     http.Header{},
+				Body:       ioutil.NopCloser(strings.NewReader(test.body)),
+			}
+			if test.contentType != "" {
+				resp.Header.Set("Content-Type", test.contentType)
+			}
+
+			response, err := crawler.newResponseOf(NewLink(fakeLink), resp, nil)
+			require.NoError(t, err, "no error expected")
+			require.Equal(t, test.expectedContentType, response.ContentType, "should be equal")
+			require.Equal(t, test.expectedParsed, response.BodyForQueries != nil, "should parse text bodies of given statuses only")
+			require.Equal(t, test.expectedRawBody, string(response.RawBody), "should read only head of binary bodies")
+		})
+	}
+}
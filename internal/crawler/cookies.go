@@ -0,0 +1,117 @@
+package crawler
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+//FindingCookieSecurity type of [crawler.CookieCheck] findings
+const FindingCookieSecurity = "cookie-security"
+
+//MaxSessionCookieLifetime max lifetime of a session cookie not considered too long
+const MaxSessionCookieLifetime = 24 * time.Hour
+
+var rgxSessionCookieName = regexp.MustCompile(`(?i)(sess|sid|token|auth|login)`)
+
+//CookieCheck passive check for insecure Set-Cookie attributes, reported once per host & cookie name
+type CookieCheck struct{}
+
+//Type returns type of the check findings
+func (CookieCheck) Type() string {
+	return FindingCookieSecurity
+}
+
+//Check returns findings for insecure cookies set by a given response
+func (check CookieCheck) Check(resp *Response) []*Finding {
+	if resp.Header == nil || resp.VisitedLink == nil {
+		return nil
+	}
+	pageURL, err := url.Parse(resp.VisitedLink.URL)
+	if err != nil {
+		return nil
+	}
+
+	var result []*Finding
+	for _, rawCookie := range resp.Header.Values("Set-Cookie") {
+		cookies := (&http.Response{Header: http.Header{"Set-Cookie": []string{rawCookie}}}).Cookies()
+		if len(cookies) == 0 {
+			continue
+		}
+
+		for _, issue := range cookieIssues(cookies[0], pageURL) {
+			result = append(result, &Finding{
+				Type:     check.Type(),
+				URL:      resp.VisitedLink.URL,
+				Scope:    pageURL.Host,
+				Param:    cookies[0].Name,
+				Payload:  issue.payload,
+				Evidence: "Set-Cookie: " + rawCookie,
+				Severity: issue.severity,
+			})
+		}
+	}
+
+	return result
+}
+
+type cookieIssue struct {
+	payload  string
+	severity string
+}
+
+func cookieIssues(cookie *http.Cookie, pageURL *url.URL) []cookieIssue {
+	isSession := rgxSessionCookieName.MatchString(cookie.Name)
+	importance := SeverityLow
+	if isSession {
+		importance = SeverityMedium
+	}
+
+	var result []cookieIssue
+	if pageURL.Scheme == "http" {
+		result = append(result, cookieIssue{"cookie set over plain HTTP", importance})
+	}
+	if !cookie.Secure {
+		result = append(result, cookieIssue{"missing Secure attribute", importance})
+	}
+	if !cookie.HttpOnly {
+		result = append(result, cookieIssue{"missing HttpOnly attribute", importance})
+	}
+	switch {
+	case cookie.SameSite == 0 || cookie.SameSite == http.SameSiteDefaultMode:
+		result = append(result, cookieIssue{"missing SameSite attribute", SeverityLow})
+	case cookie.SameSite == http.SameSiteNoneMode && !cookie.Secure:
+		result = append(result, cookieIssue{"SameSite=None without Secure attribute", importance})
+	}
+	if isBroadDomain(cookie.Domain, pageURL.Hostname()) {
+		result = append(result, cookieIssue{"overly broad Domain attribute: " + cookie.Domain, SeverityLow})
+	}
+	if cookie.Path == "/" && strings.Count(strings.Trim(pageURL.Path, "/"), "/") > 0 {
+		result = append(result, cookieIssue{"overly broad Path attribute: cookie set under " + pageURL.Path, SeverityInfo})
+	}
+	if isSession && cookieLifetime(cookie) > MaxSessionCookieLifetime {
+		result = append(result, cookieIssue{"long-lived session cookie", SeverityLow})
+	}
+
+	return result
+}
+
+//isBroadDomain returns true if the cookie is shared with parent domain of the host that set it
+func isBroadDomain(domain, host string) bool {
+	domain = strings.TrimPrefix(strings.ToLower(domain), ".")
+
+	return domain != "" && domain != strings.ToLower(host)
+}
+
+func cookieLifetime(cookie *http.Cookie) time.Duration {
+	switch {
+	case cookie.MaxAge > 0:
+		return time.Duration(cookie.MaxAge) * time.Second
+	case !cookie.Expires.IsZero():
+		return time.Until(cookie.Expires)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,61 @@
+package crawler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCookieCheck(t *testing.T) {
+	tabTests := []struct {
+		name     string
+		link     string
+		cookie   string
+		expected []string
+	}{
+		{
+			name:     "secure cookie",
+			link:     fakeLink,
+			cookie:   "session=abc; Path=/; Secure; HttpOnly; SameSite=Lax",
+			expected: nil,
+		},
+		{
+			name:   "insecure session cookie over http",
+			link:   "http://shop.this.is.link/account/login",
+			cookie: "PHPSESSID=abc; Domain=this.is.link; Path=/; Max-Age=31536000",
+			expected: []string{
+				"cookie set over plain HTTP",
+				"missing Secure attribute",
+				"missing HttpOnly attribute",
+				"missing SameSite attribute",
+				"overly broad Domain attribute: this.is.link",
+				"overly broad Path attribute: cookie set under /account/login",
+				"long-lived session cookie",
+			},
+		},
+		{
+			name:     "SameSite=None without Secure",
+			link:     fakeLink,
+			cookie:   "lang=en; HttpOnly; SameSite=None",
+			expected: []string{"missing Secure attribute", "SameSite=None without Secure attribute"},
+		},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.name, func(t *testing.T) {
+			response := &Response{
+				VisitedLink: NewLink(test.link),
+				Header:      http.Header{"Set-Cookie": []string{test.cookie}},
+			}
+
+			var got []string
+			for _, finding := range (CookieCheck{}).Check(response) {
+				require.Equal(t, "Set-Cookie: "+test.cookie, finding.Evidence, "should have raw header as evidence")
+				require.Equal(t, test.link, finding.URL, "should have url that set the cookie")
+				got = append(got, finding.Payload)
+			}
+			require.Equal(t, test.expected, got, "should be equal")
+		})
+	}
+}
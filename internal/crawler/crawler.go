@@ -1,8 +1,10 @@
 package crawler
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"log"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -16,129 +18,382 @@ var ErrContextDone = fmt.Errorf("exit on context done")
 
 //Crawler defines struct to do a "crawl" job with a given url
 type Crawler struct {
-	URL      *url.URL  //given url representation
-	Result   *sync.Map //map for result holding
-	MaxJumps int       //max depth of visiting url's found inside parent url
-	ctx      context.Context
-	ch       chan struct{}
-	wg       *sync.WaitGroup
-	client   httpClientGetter
+	URL           *url.URL         //given url representation
+	Result        *sync.Map        //map for result holding
+	MaxJumps      int              //max depth of visiting url's found inside parent url
+	Budget        Budget           //resource limits of the crawl
+	Include       []*regexp.Regexp //if not empty, only links matching any of them are visited
+	Exclude       []*regexp.Regexp //links matching any of them are not visited
+	Headers       http.Header      //headers to add to every request
+	Traps         *TrapDetector    //crawler traps detector, nil - no detection
+	Checks        []PassiveCheck   //passive checks to run on every crawled page
+	Findings      *Findings        //findings of passive checks
+	DetectSoft404 bool             //mark pages looking like host response for nonexistent paths as soft-404
+	MaxRedirects  int              //max number of redirects to follow for a single request, 0 - do not follow
+	ParseStatuses StatusClasses    //status classes of responses whose bodies are parsed for links & features
+	Recorder      *HARRecorder     //records requests & responses of the crawl, nil - no recording
+	Graph         *SiteGraph       //graph of links between crawled pages, nil - not recorded
+	Previous      PreviousCrawl    //pages of the previous crawl for incremental crawling, nil - full crawl
+	ctx           context.Context
+	client        httpClientDoer
+	limiter       *rateLimiter
+	threads       int
+	usage         budgetUsage
+	baselines     sync.Map
+
+	frontier       *Frontier //links found but not visited yet
+	workersOnce    sync.Once
+	visitingOnce   sync.Once
+	visiting       chan struct{} //slots of links visited concurrently by VisitLink
+	workers        sync.WaitGroup
+	stopCheckpoint func()
 }
 
-type httpClientGetter interface {
-	Get(link string) (resp *http.Response, err error)
+type httpClientDoer interface {
+	Do(req *http.Request) (resp *http.Response, err error)
 }
 
 //NewCrawler is a [crawler.Crawler] constructor
 func NewCrawler(ctx context.Context, urlCrawl *url.URL) *Crawler {
-	ch := make(chan struct{}, 1)
-	ch <- struct{}{}
-
 	client := new(http.Client)
 	client.Timeout = 7 * time.Second
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse //redirects are followed by makeGetRequest to record & scope every hop
+	}
 
 	return &Crawler{
-		URL:      urlCrawl,
-		Result:   new(sync.Map),
-		MaxJumps: 0,
-		ctx:      ctx,
-		wg:       new(sync.WaitGroup),
-		ch:       ch,
-		client:   client,
+		URL:           urlCrawl,
+		Result:        new(sync.Map),
+		MaxJumps:      0,
+		Traps:         NewTrapDetector(),
+		Findings:      NewFindings(),
+		MaxRedirects:  DefaultMaxRedirects,
+		ParseStatuses: DefaultParseStatusClasses,
+		ctx:           ctx,
+		threads:       1,
+		frontier:      NewFrontier(BreadthFirst{}),
+		client:        client,
 	}
 }
 
 //SetNumberOfThreads sets max number of goroutines to execute crawling operations
 func (cr *Crawler) SetNumberOfThreads(num int) {
-	ch := make(chan struct{}, num)
-	ch <- struct{}{}
-	cr.ch = ch
+	cr.threads = num
+}
+
+//SetPrioritizer sets order of visiting found links, should be called before crawling
+func (cr *Crawler) SetPrioritizer(prioritizer Prioritizer) {
+	cr.frontier = NewFrontier(prioritizer)
+}
+
+//SetRateLimit limits crawler to a given number of requests per second, 0 - unlimited
+func (cr *Crawler) SetRateLimit(perSecond float64) {
+	cr.limiter = nil
+	if perSecond <= 0 {
+		return
+	}
+	interval := time.Duration(float64(time.Second) / perSecond)
+	if interval <= 0 {
+		interval = time.Nanosecond //rates above 1e9 per second truncate to zero interval
+	}
+	cr.limiter = &rateLimiter{interval: interval}
 }
 
 //Wait waits until crawler completes its task or exits on context
 func (cr *Crawler) Wait() {
-	cr.wg.Wait()
-	close(cr.ch)
+	cr.frontier.Wait()
+	cr.frontier.Close()
+	cr.workers.Wait()
+	if cr.stopCheckpoint != nil {
+		cr.stopCheckpoint()
+	}
+}
+
+//runWorkers calls job for indexes from 0 to count-1 in cr.threads goroutines, stops early on context done
+func (cr *Crawler) runWorkers(count int, job func(i int)) {
+	jobs := make(chan int)
+	wg := new(sync.WaitGroup)
+	for w := 0; w < cr.threads; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				job(i)
+			}
+		}()
+	}
+
+	for i := 0; i < count && !cr.isDone(); i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
 }
 
-//ExploreLink visits given link & puts received results from it into cr.Result
+//ExploreLink visits given link & links found on it in cr.threads goroutines, puts received results into cr.Result,
+//see [crawler.Crawler.Wait]
 func (cr *Crawler) ExploreLink(link *Link) {
-	cr.wg.Add(1)
+	cr.enqueue(link)
+}
+
+//enqueue adds given links into the frontier & starts workers visiting it
+func (cr *Crawler) enqueue(links ...*Link) {
+	cr.frontier.Push(links...)
+	cr.workersOnce.Do(func() {
+		for w := 0; w < cr.threads; w++ {
+			cr.workers.Add(1)
+			go cr.work()
+		}
+	})
+}
 
-	defer func() {
-		<-cr.ch
-		cr.wg.Done()
-	}()
+//work explores links of the frontier until it's closed
+func (cr *Crawler) work() {
+	defer cr.workers.Done()
+	for {
+		link, ok := cr.frontier.Pop()
+		if !ok {
+			return
+		}
+		cr.explore(link)
+	}
+}
+
+//explore visits given link of the frontier & queues links found on it,
+//the link is kept in the frontier if it's not visited because of crawler exit, so checkpoint has it
+func (cr *Crawler) explore(link *Link) {
+	if cr.shouldExit() {
+		cr.frontier.Done(link, true, nil)
 
-	if cr.shouldExit() ||
-		!cr.canVisitLink(link.URL) ||
-		cr.MaxJumps < link.Jumps {
 		return
 	}
+	pageResponse, ok := cr.fetchPage(link)
+	if !ok {
+		cr.frontier.Done(link, cr.shouldExit(), nil)
+
+		return
+	}
+
+	cr.frontier.Done(link, false, cr.linksToVisit(pageResponse))
+}
+
+//VisitLink visits a single link without following links found on it, returns the page & its links to visit,
+//nil if the link can't be visited, e.g. it was visited already; used by replicas of distributed crawl,
+//at most cr.threads links are visited concurrently
+func (cr *Crawler) VisitLink(link *Link) (*Response, []*Link) {
+	cr.visitingOnce.Do(func() {
+		cr.visiting = make(chan struct{}, cr.threads)
+	})
+	cr.visiting <- struct{}{}
+	defer func() { <-cr.visiting }()
+
+	if cr.shouldExit() {
+		return nil, nil
+	}
+	pageResponse, ok := cr.fetchPage(link)
+	if !ok {
+		return nil, nil
+	}
+
+	return pageResponse, cr.linksToVisit(pageResponse)
+}
+
+//AddVisited stores a page visited by another crawler into cr.Result counting it into pages budget, returns false if the budget is exhausted
+func (cr *Crawler) AddVisited(resp *Response) bool {
+	if !cr.takePage() {
+		return false
+	}
+	cr.Result.Store(resp.VisitedLink.URL, resp)
+
+	return true
+}
+
+//fetchPage requests a given link if it can be visited, stores the page into cr.Result & returns it, false if link was not visited
+func (cr *Crawler) fetchPage(link *Link) (*Response, bool) {
+	if !cr.canVisitLink(link.URL) ||
+		cr.MaxJumps < link.Jumps ||
+		!cr.takePatternSlot(link.URL) ||
+		!cr.takePage() {
+		return nil, false
+	}
 
 	cr.Result.Store(link.URL, &Response{})
-	pageResponse, err := cr.makeGetRequest(link)
+	pageResponse, err := cr.makeRequest(link, cr.conditionalHeader(link.URL))
 	if err != nil {
 		cr.Result.Delete(link.URL) //???
 
-		return
+		return nil, false
 	}
+	notModified := pageResponse.StatusCode == http.StatusNotModified
 	pageResponse.FillResponseParameters()
+	cr.compareWithPrevious(pageResponse)
+	cr.Graph.AddNode(link.URL, pageResponse.StatusCode)
+	cr.markSoft404(pageResponse)
+	cr.Traps.ObservePage(pageResponse)
+	if !notModified {
+		cr.runPassiveChecks(pageResponse) //headers of 304 response are not the headers of the page
+	}
 	cr.Result.Store(link.URL, pageResponse)
 
-	go cr.queueLinksVisit(pageResponse)
-	cr.wg.Add(1)
+	return pageResponse, true
 }
 
-func (cr *Crawler) queueLinksVisit(pageResponse *Response) {
-	defer cr.wg.Done()
+//Seed visits given links in addition to links found on crawled pages, should be called before [crawler.Crawler.Wait]
+func (cr *Crawler) Seed(links []*Link) {
+	var toVisit []*Link
+	for _, l := range links {
+		if cr.canVisitLink(l.URL) {
+			l.Extractor = ExtractorSeed
+			toVisit = append(toVisit, l)
+		}
+	}
 
-	links := pageResponse.ParseLinksFromResponse(cr)
-	pageResponse.ClearResponseBody()
-	if links == nil {
-		return
+	cr.enqueue(toVisit...)
+}
+
+//linksToVisit returns links of a given page to visit & adds all its links to the site graph, clears the page body
+func (cr *Crawler) linksToVisit(pageResponse *Response) []*Link {
+	links, reused := cr.previousLinks(pageResponse)
+	if !reused {
+		links = pageResponse.ParseLinksFromResponse(cr)
 	}
+	links = append(links, pageResponse.redirectLinks()...)
+	pageResponse.ClearResponseBody()
 
+	var toVisit []*Link
 	for _, l := range links {
-		if !cr.canVisitLink(l.URL) {
-			continue
-		}
-
-		select {
-		case cr.ch <- struct{}{}:
-			{
-				go cr.ExploreLink(l)
-			}
-		case <-cr.ctx.Done():
-			return
+		cr.Graph.AddEdge(pageResponse.VisitedLink.URL, l.URL, l.Extractor)
+		if cr.canVisitLink(l.URL) && l.Jumps <= cr.MaxJumps && !cr.Traps.IsTrap(pageResponse.VisitedLink, l) {
+			toVisit = append(toVisit, l)
 		}
 	}
+
+	return toVisit
 }
 
 func (cr *Crawler) canVisitLink(link string) bool {
 	_, wasVisited := cr.Result.Load(link)
 
+	return !wasVisited && cr.inScope(link)
+}
+
+//inScope returns true if a given link is under crawled url & passes include/exclude filters
+func (cr *Crawler) inScope(link string) bool {
 	rgxForHost := fmt.Sprintf("%s.*", strings.ReplaceAll(cr.URL.String(), ".", "\\."))
 	isOurHost, _ := regexp.MatchString(rgxForHost, link)
 
-	return !wasVisited && isOurHost
+	return isOurHost && cr.passesFilters(link)
+}
+
+func (cr *Crawler) passesFilters(link string) bool {
+	if cr.URL != nil && link == cr.URL.String() {
+		return true
+	}
+	for _, rgx := range cr.Exclude {
+		if rgx.MatchString(link) {
+			return false
+		}
+	}
+	if len(cr.Include) == 0 {
+		return true
+	}
+	for _, rgx := range cr.Include {
+		if rgx.MatchString(link) {
+			return true
+		}
+	}
+
+	return false
 }
 
+func (cr *Crawler) waitRateLimit() bool {
+	if cr.limiter == nil {
+		return true
+	}
+
+	return cr.limiter.wait(cr.ctx)
+}
+
+//makeGetRequest requests a given link following in-scope redirects, all redirect hops are stored into result.Redirects
 func (cr *Crawler) makeGetRequest(link *Link) (*Response, error) {
-	if cr.shouldExit() {
-		return nil, ErrContextDone
+	return cr.makeRequest(link, nil)
+}
+
+//makeRequest is [crawler.Crawler.makeGetRequest] sending given extra headers with the first request of redirect chain
+func (cr *Crawler) makeRequest(link *Link, header http.Header) (*Response, error) {
+	var redirects []*Redirect
+	requestURL := link.URL
+	requested := map[string]bool{requestURL: true}
+	for {
+		started := time.Now()
+		req, resp, err := cr.doGetRequest(requestURL, header)
+		header = nil
+		if err != nil {
+			return nil, err
+		}
+		wait := time.Since(started)
+
+		location := redirectLocation(resp, requestURL)
+		if location != "" {
+			redirects = append(redirects, &Redirect{URL: requestURL, StatusCode: resp.StatusCode, Location: location})
+		}
+		if location != "" && requested[location] {
+			log.Printf("Redirect loop, stopped following:\t%s\tlast location: %s\n", link.URL, location)
+		} else if location != "" && cr.MaxRedirects > 0 && len(redirects) > cr.MaxRedirects {
+			log.Printf("Too many redirects, stopped following:\t%s\tlast location: %s\n", link.URL, location)
+		}
+		if location == "" || len(redirects) > cr.MaxRedirects || requested[location] || !cr.inScope(location) {
+			result, err := cr.newResponseOf(link, resp, redirects)
+			if err == nil {
+				cr.Recorder.record(req, resp, result.RawBody, started, wait, time.Since(started)-wait)
+			}
+
+			return result, err
+		}
+
+		resp.Body.Close()
+		cr.Recorder.record(req, resp, nil, started, wait, 0)
+		requested[location] = true
+		requestURL = location
 	}
+}
 
-	resp, err := cr.client.Get(link.URL)
+func (cr *Crawler) doGetRequest(link string, header http.Header) (*http.Request, *http.Response, error) {
+	//crawl budget is checked by callers, so post-crawl phases can request pages after it's exhausted
+	if cr.isDone() || !cr.waitRateLimit() {
+		return nil, nil, ErrContextDone
+	}
+
+	req, err := http.NewRequest(http.MethodGet, link, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error creating GET request: %w", err)
+	}
+	for key, values := range cr.Headers {
+		req.Header[key] = values
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+
+	resp, err := cr.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("error in GET request: %w", err)
+		return nil, nil, fmt.Errorf("error in GET request: %w", err)
 	}
+
+	return req, resp, nil
+}
+
+//newResponseOf converts a given http response to [crawler.Response], closes its body
+func (cr *Crawler) newResponseOf(link *Link, resp *http.Response, redirects []*Redirect) (*Response, error) {
 	defer resp.Body.Close()
 
 	result := NewResponse(link, resp.StatusCode)
+	result.Header = resp.Header
+	result.Redirects = redirects
+	result.ContentType = mediaTypeOf(resp.Header.Get("Content-Type"))
 
-	if resp.StatusCode == http.StatusOK {
-		if err = result.FillResponseBody(resp.Body); err != nil {
+	if cr.ParseStatuses.Includes(resp.StatusCode) {
+		if err := result.readBody(bufio.NewReader(cr.limitBody(resp.Body))); err != nil {
 			return nil, fmt.Errorf("error converting response body to goquery: %w", err)
 		}
 	}
@@ -166,11 +421,17 @@ func (cr *Crawler) absoluteURL(u string) string {
 	if absURL.Scheme == "//" {
 		absURL.Scheme = cr.URL.Scheme
 	}
+	cr.Traps.StripSessionIDs(absURL)
 
 	return absURL.String()
 }
 
 func (cr *Crawler) shouldExit() bool {
+	return cr.isBudgetExhausted() || cr.isDone()
+}
+
+//isDone returns true if crawler context is done, post-crawl phases check it instead of shouldExit as they have their own budgets
+func (cr *Crawler) isDone() bool {
 	if cr.ctx == nil {
 		return false
 	}
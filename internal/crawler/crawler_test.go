@@ -7,10 +7,10 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
 	"sync"
 	"testing"
-	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/stretchr/testify/require"
@@ -23,10 +23,10 @@ var fakeHtmlBodyData = "<html><body><form action='/' method='get'>stub</form></b
 type httpClientStub struct {
 }
 
-func (hCl *httpClientStub) Get(link string) (*http.Response, error) {
+func (hCl *httpClientStub) Do(req *http.Request) (*http.Response, error) {
 	fakeHtmlBody := ioutil.NopCloser(strings.NewReader(fakeHtmlBodyData))
 
-	if link == fakeLink {
+	if req.URL.String() == fakeLink {
 		return &http.Response{
 			StatusCode: http.StatusOK,
 			Body:       fakeHtmlBody,
@@ -44,17 +44,26 @@ func TestSetNumberOfThreads(t *testing.T) {
 	crawler := NewCrawler(context.Background(), &url.URL{})
 	expectedNum := 5
 	crawler.SetNumberOfThreads(expectedNum)
-	require.EqualValuesf(t, expectedNum, cap(crawler.ch), "should set %d threads", expectedNum)
+	require.EqualValuesf(t, expectedNum, crawler.threads, "should set %d threads", expectedNum)
+}
+
+func TestSetRateLimit(t *testing.T) {
+	crawler := NewCrawler(context.Background(), &url.URL{})
+	crawler.SetRateLimit(0)
+	require.Nil(t, crawler.limiter, "should not limit on zero rate")
+	crawler.SetRateLimit(1000)
+	require.NotNil(t, crawler.limiter, "should limit on positive rate")
+	crawler.SetRateLimit(1e12)
+	require.Positive(t, crawler.limiter.interval, "should clamp interval of huge rate")
+	require.True(t, crawler.waitRateLimit(), "should pass limiter")
+	crawler.Wait()
 }
 
 func TestWait(t *testing.T) {
 	crawler := NewCrawler(context.Background(), &url.URL{})
 	crawler.Wait()
-	for cc := range crawler.ch {
-		_ = cc
-	}
-	_, ok := <-crawler.ch
-	require.False(t, ok, "should close crawlers' chan")
+	_, ok := crawler.frontier.Pop()
+	require.False(t, ok, "should close crawlers' frontier")
 }
 
 func TestAbsoluteUrl(t *testing.T) {
@@ -97,7 +106,7 @@ func TestExploreLink(t *testing.T) {
 		VisitedLink: link,
 		StatusCode:  http.StatusOK,
 	}
-	someResponse.BodyParams[HasFormTag] = true
+	someResponse.SetFeature(FeatureForm)
 	smWithResponse := &sync.Map{}
 	smWithResponse.Store(fakeLink, someResponse)
 	smWithEmpty := &sync.Map{}
@@ -173,6 +182,7 @@ func TestExploreLink(t *testing.T) {
 	for _, test := range tabTests {
 		t.Run(test.name, func(t *testing.T) {
 			test.crawler.ExploreLink(test.link)
+			test.crawler.Wait()
 			requireSyncMapsAreEqual(t, test.expected, test.crawler.Result, "should return expected result")
 		})
 	}
@@ -196,7 +206,7 @@ func requireSyncMapsAreEqual(t *testing.T, a, b *sync.Map, msg string) {
 			mapB[key] = &Response{
 				VisitedLink: resp.VisitedLink,
 				StatusCode:  resp.StatusCode,
-				BodyParams:  resp.BodyParams,
+				Features:    resp.Features,
 			}
 		}
 
@@ -206,10 +216,9 @@ func requireSyncMapsAreEqual(t *testing.T, a, b *sync.Map, msg string) {
 	require.Equal(t, mapA, mapB, msg)
 }
 
-func TestQueueLinksVisit(t *testing.T) {
+func TestLinksToVisit(t *testing.T) {
 	urlFake, _ := url.Parse(fakeLink)
 	crawler := NewCrawler(context.Background(), urlFake)
-	crawler.client = &httpClientStub{}
 	crawler.MaxJumps = 10
 	crawler.Result.Store(fakeLink+"search", &Response{})
 
@@ -222,18 +231,103 @@ func TestQueueLinksVisit(t *testing.T) {
 		StatusCode:     http.StatusOK,
 		BodyForQueries: queryWithLink,
 	}
-	response.BodyParams[HasFormTag] = true
 
-	crawler.wg.Add(1)
-	<-crawler.ch
-	crawler.queueLinksVisit(response)
-	time.Sleep(time.Millisecond)
-	crawler.Wait()
+	require.Equal(t, []*Link{{URL: fakeLink, Jumps: 1, Extractor: "a[href]"}}, crawler.linksToVisit(response), "visited links should be skipped")
+	require.Nil(t, response.BodyForQueries, "should clear body")
+}
+
+func TestPassesFilters(t *testing.T) {
+	urlFake, _ := url.Parse(fakeLink)
+	crawler := NewCrawler(context.Background(), urlFake)
+	crawler.Include = []*regexp.Regexp{regexp.MustCompile(`/shop/`)}
+	crawler.Exclude = []*regexp.Regexp{regexp.MustCompile(`/shop/cart`)}
+
+	tabTests := []struct {
+		name     string
+		link     string
+		expected bool
+	}{
+		{
+			name:     "root link always passes",
+			link:     fakeLink,
+			expected: true,
+		},
+		{
+			name:     "included link",
+			link:     fakeLink + "shop/item",
+			expected: true,
+		},
+		{
+			name:     "excluded link",
+			link:     fakeLink + "shop/cart",
+			expected: false,
+		},
+		{
+			name:     "not included link",
+			link:     fakeLink + "blog/",
+			expected: false,
+		},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, crawler.passesFilters(test.link), "should be equal")
+		})
+	}
+}
+
+type httpClientHeadersStub struct {
+	received http.Header
+}
+
+func (hCl *httpClientHeadersStub) Do(req *http.Request) (*http.Response, error) {
+	hCl.received = req.Header
+
+	return &http.Response{
+		StatusCode: http.StatusNotFound,
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
+func TestMakeGetRequestHeaders(t *testing.T) {
+	stub := &httpClientHeadersStub{}
+	crawler := NewCrawler(context.Background(), &url.URL{})
+	crawler.client = stub
+	crawler.Headers = http.Header{"User-Agent": []string{"test-agent"}}
 
-	response.VisitedLink.Jumps++
-	expectedSM := &sync.Map{}
-	expectedSM.Store(fakeLink, response)
-	expectedSM.Store(fakeLink+"search", &Response{})
+	_, err := crawler.makeGetRequest(NewLink(fakeLink))
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, "test-agent", stub.received.Get("User-Agent"), "should send given headers")
+}
+
+func TestVisitLink(t *testing.T) {
+	urlFake, _ := url.Parse(fakeLink)
+	crawler := NewCrawler(context.Background(), urlFake)
+	crawler.MaxJumps = 1
+	crawler.client = &httpClientSiteStub{pages: map[string]string{
+		fakeLink: "<a href='/a'>a</a><a href='/'>self</a><a href='https://other.site/'>out</a>",
+	}}
+
+	resp, links := crawler.VisitLink(NewLink(fakeLink))
+	require.NotNil(t, resp, "should visit the link")
+	require.Nil(t, resp.RawBody, "should clear the body")
+	require.Equal(t, []*Link{{URL: fakeLink + "a", Jumps: 1, Extractor: "a[href]"}}, links, "should return in-scope links not visited yet")
+	_, ok := crawler.Result.Load(fakeLink + "a")
+	require.False(t, ok, "should not follow found links")
+
+	resp, links = crawler.VisitLink(NewLink(fakeLink))
+	require.Nil(t, resp, "should not visit the link twice")
+	require.Nil(t, links, "should be nil")
+}
+
+func TestAddVisited(t *testing.T) {
+	urlFake, _ := url.Parse(fakeLink)
+	crawler := NewCrawler(context.Background(), urlFake)
+	crawler.Budget.MaxPages = 1
 
-	requireSyncMapsAreEqual(t, expectedSM, crawler.Result, "should be equal")
+	require.True(t, crawler.AddVisited(NewResponse(NewLink(fakeLink), http.StatusOK)), "should store the page")
+	require.False(t, crawler.AddVisited(NewResponse(NewLink(fakeLink+"a"), http.StatusOK)), "should stop on pages budget")
+	_, ok := crawler.Result.Load(fakeLink + "a")
+	require.False(t, ok, "page over the budget should not be stored")
+	require.Equal(t, StopReasonMaxPages, crawler.StopReason(), "should be equal")
 }
@@ -0,0 +1,128 @@
+package crawler
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+//FindingInfoDisclosure type of [crawler.DisclosureCheck] findings
+const FindingInfoDisclosure = "info-disclosure"
+
+//maxSnippetContext number of characters around the match kept in the evidence snippet
+const maxSnippetContext = 60
+
+//go:embed rules/disclosure.json
+var defaultDisclosureRules []byte
+
+//DisclosureRule signature of information disclosure in response body or header
+type DisclosureRule struct {
+	Name        string `json:"name"`             //rule name, reported as finding param
+	Header      string `json:"header,omitempty"` //header to match, empty - match response body
+	Pattern     string `json:"pattern"`          //regular expression to search for
+	Severity    string `json:"severity"`         //one of Severity... constants
+	Description string `json:"description"`      //issue description, reported as finding payload
+	rgx         *regexp.Regexp
+}
+
+//DisclosureCheck passive check for stack traces, debug pages, error messages, version banners etc
+type DisclosureCheck struct {
+	Rules []*DisclosureRule //rules to match responses against
+}
+
+//LoadDisclosureRules reads rules from a given json file, from embedded default rules if path is empty
+func LoadDisclosureRules(path string) ([]*DisclosureRule, error) {
+	data := defaultDisclosureRules
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("error reading disclosure rules: %w", err)
+		}
+	}
+
+	var rules []*DisclosureRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("error parsing disclosure rules: %w", err)
+	}
+	for _, rule := range rules {
+		rgx, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("wrong pattern of disclosure rule %s: %w", rule.Name, err)
+		}
+		rule.rgx = rgx
+	}
+
+	return rules, nil
+}
+
+//NewDisclosureCheck is a [crawler.DisclosureCheck] constructor
+func NewDisclosureCheck(rules []*DisclosureRule) *DisclosureCheck {
+	return &DisclosureCheck{Rules: rules}
+}
+
+//Type returns type of the check findings
+func (*DisclosureCheck) Type() string {
+	return FindingInfoDisclosure
+}
+
+//Check returns findings for rules matching a given response body or headers
+func (check *DisclosureCheck) Check(resp *Response) []*Finding {
+	if resp.VisitedLink == nil {
+		return nil
+	}
+
+	var result []*Finding
+	for _, rule := range check.Rules {
+		scope, snippet := rule.match(resp)
+		if snippet == "" {
+			continue
+		}
+		result = append(result, &Finding{
+			Type:     check.Type(),
+			URL:      resp.VisitedLink.URL,
+			Scope:    scope,
+			Param:    rule.Name,
+			Payload:  rule.Description,
+			Evidence: snippet,
+			Severity: rule.Severity,
+		})
+	}
+
+	return result
+}
+
+//match returns finding scope & matched snippet, empty snippet if the rule does not match
+func (rule *DisclosureRule) match(resp *Response) (string, string) {
+	if rule.Header != "" {
+		for _, value := range resp.Header.Values(rule.Header) {
+			if found := rule.rgx.FindString(value); found != "" {
+				return hostOf(resp.VisitedLink.URL), rule.Header + ": " + value
+			}
+		}
+
+		return "", ""
+	}
+
+	loc := rule.rgx.FindIndex(resp.RawBody)
+	if loc == nil {
+		return "", ""
+	}
+
+	return resp.VisitedLink.URL, snippet(resp.RawBody, loc[0], loc[1])
+}
+
+//snippet returns matched part of the body with some context around it
+func snippet(body []byte, start, end int) string {
+	from, to := start-maxSnippetContext, end+maxSnippetContext
+	if from < 0 {
+		from = 0
+	}
+	if to > len(body) {
+		to = len(body)
+	}
+
+	return strings.ToValidUTF8(strings.Join(strings.Fields(string(body[from:to])), " "), "")
+}
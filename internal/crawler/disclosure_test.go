@@ -0,0 +1,102 @@
+package crawler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadDisclosureRules(t *testing.T) {
+	rules, err := LoadDisclosureRules("")
+	require.NoError(t, err, "default rules should be valid")
+	require.NotEmpty(t, rules, "default rules should not be empty")
+
+	wrongRules := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(wrongRules, []byte(`[{"name":"wrong","pattern":"("}]`), 0o600))
+	_, err = LoadDisclosureRules(wrongRules)
+	require.Error(t, err, "should fail on wrong pattern")
+
+	_, err = LoadDisclosureRules(filepath.Join(t.TempDir(), "absent.json"))
+	require.Error(t, err, "should fail on absent file")
+}
+
+func TestDisclosureCheck(t *testing.T) {
+	rules, _ := LoadDisclosureRules("")
+	check := NewDisclosureCheck(rules)
+
+	tabTests := []struct {
+		name          string
+		response      *Response
+		expectedRules []string
+		expectedScope string
+	}{
+		{
+			name: "clean page",
+			response: &Response{
+				VisitedLink: NewLink(fakeLink),
+				Header:      http.Header{"Server": []string{"nginx"}},
+				RawBody:     []byte("<html><body>Welcome</body></html>"),
+			},
+		},
+		{
+			name: "sql error in body",
+			response: &Response{
+				VisitedLink: NewLink(fakeLink + "item?id=1'"),
+				RawBody:     []byte("<p>You have an error in your SQL syntax; check the manual</p>"),
+			},
+			expectedRules: []string{"sql-error"},
+			expectedScope: fakeLink + "item?id=1'",
+		},
+		{
+			name: "version banner in header",
+			response: &Response{
+				VisitedLink: NewLink(fakeLink),
+				Header:      http.Header{"Server": []string{"Apache/2.4.1 (Unix)"}},
+			},
+			expectedRules: []string{"server-version"},
+			expectedScope: "this.is.link",
+		},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotRules []string
+			for _, finding := range check.Check(test.response) {
+				require.Equal(t, test.expectedScope, finding.Scope, "should be scoped")
+				require.NotEmpty(t, finding.Evidence, "should have matched snippet as evidence")
+				gotRules = append(gotRules, finding.Param)
+			}
+			require.Equal(t, test.expectedRules, gotRules, "should be equal")
+		})
+	}
+}
+
+func TestDisclosureOnErrorPage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, "<p>You have an error in your SQL syntax; check the manual</p>")
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL + "/")
+
+	rules, _ := LoadDisclosureRules("")
+	crawler := NewCrawler(context.Background(), serverURL)
+	crawler.Checks = []PassiveCheck{NewDisclosureCheck(rules)}
+	crawler.ExploreLink(NewLink(serverURL.String()))
+	crawler.Wait()
+
+	require.Len(t, crawler.Findings.ByType(FindingInfoDisclosure), 1, "bodies of error pages should be scanned by default")
+}
+
+func TestSnippet(t *testing.T) {
+	body := []byte("start\n  MATCH end")
+	require.Equal(t, "start MATCH end", snippet(body, 8, 13), "should keep context around match")
+}
@@ -0,0 +1,195 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//Feature name of a response property used to route urls between test-services
+type Feature string
+
+//Built-in features of responses
+const (
+	FeatureForm          = Feature("form")           //page has a form
+	FeatureUploadForm    = Feature("upload-form")    //page has a file upload form
+	FeatureLoginForm     = Feature("login-form")     //page has a form with password field
+	FeatureQueryParams   = Feature("query-params")   //url has query parameters
+	FeaturePathParams    = Feature("path-params")    //url has id-like path segments, e.g. /users/42
+	FeatureStatusError   = Feature("status-error")   //response status is 5xx
+	FeatureRedirectParam = Feature("redirect-param") //open redirect candidate, see [crawler.RedirectParams]
+	FeatureJSON          = Feature("json")           //response body is json
+)
+
+//FeatureSet set of features of a response, serialized to json as a sorted list of names
+type FeatureSet map[Feature]bool
+
+//FeatureExtractor returns true if a given response has the feature
+type FeatureExtractor func(resp *Response) bool
+
+type registeredFeature struct {
+	feature Feature
+	extract FeatureExtractor
+}
+
+var (
+	featuresMu sync.RWMutex
+	features   []registeredFeature
+)
+
+func init() {
+	builtins := []registeredFeature{
+		{FeatureForm, hasFormTag},
+		{FeatureUploadForm, hasUploadForm},
+		{FeatureLoginForm, hasLoginForm},
+		{FeatureQueryParams, hasQueryParams},
+		{FeaturePathParams, hasPathParams},
+		{FeatureStatusError, hasStatusError},
+		{FeatureRedirectParam, hasRedirectParam},
+		{FeatureJSON, hasJSONBody},
+	}
+	for _, builtin := range builtins {
+		if err := RegisterFeature(builtin.feature, builtin.extract); err != nil {
+			panic(err)
+		}
+	}
+}
+
+//RegisterFeature adds extractor of a named feature run on every crawled response, should be called before crawling
+func RegisterFeature(feature Feature, extractor FeatureExtractor) error {
+	featuresMu.Lock()
+	defer featuresMu.Unlock()
+
+	for _, registered := range features {
+		if registered.feature == feature {
+			return fmt.Errorf("feature %s is already registered", feature)
+		}
+	}
+	features = append(features, registeredFeature{feature: feature, extract: extractor})
+
+	return nil
+}
+
+//RegisteredFeatures returns names of all registered features in order of registration
+func RegisteredFeatures() []Feature {
+	featuresMu.RLock()
+	defer featuresMu.RUnlock()
+
+	result := make([]Feature, 0, len(features))
+	for _, registered := range features {
+		result = append(result, registered.feature)
+	}
+
+	return result
+}
+
+//extractFeatures runs all registered extractors on a given response
+func (resp *Response) extractFeatures() {
+	featuresMu.RLock()
+	defer featuresMu.RUnlock()
+
+	for _, registered := range features {
+		if registered.extract(resp) {
+			resp.SetFeature(registered.feature)
+		}
+	}
+}
+
+//SetFeature adds given features to resp.Features
+func (resp *Response) SetFeature(features ...Feature) {
+	if resp.Features == nil {
+		resp.Features = FeatureSet{}
+	}
+	for _, feature := range features {
+		resp.Features[feature] = true
+	}
+}
+
+//HasAnyFeature returns true if resp.Features contains at least one of given features, false - otherwise
+func (resp *Response) HasAnyFeature(features ...Feature) bool {
+	for _, feature := range features {
+		if resp.Features[feature] {
+			return true
+		}
+	}
+
+	return false
+}
+
+//Names returns sorted names of the features in the set
+func (fs FeatureSet) Names() []string {
+	result := make([]string, 0, len(fs))
+	for feature, ok := range fs {
+		if ok {
+			result = append(result, string(feature))
+		}
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+//MarshalJSON encodes the set as a sorted list of feature names
+func (fs FeatureSet) MarshalJSON() ([]byte, error) {
+	return json.Marshal(fs.Names())
+}
+
+//UnmarshalJSON decodes the set from a list of feature names
+func (fs *FeatureSet) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+
+	*fs = FeatureSet{}
+	for _, name := range names {
+		(*fs)[Feature(name)] = true
+	}
+
+	return nil
+}
+
+func hasFormTag(resp *Response) bool {
+	return resp.BodyForQueries != nil && resp.BodyForQueries.Find("form").Length() > 0
+}
+
+func hasUploadForm(resp *Response) bool {
+	return resp.BodyForQueries != nil &&
+		resp.BodyForQueries.Find(`form input[type="file" i], form[enctype^="multipart/form-data" i]`).Length() > 0
+}
+
+func hasLoginForm(resp *Response) bool {
+	return resp.BodyForQueries != nil && resp.BodyForQueries.Find(`form input[type="password" i]`).Length() > 0
+}
+
+func hasQueryParams(resp *Response) bool {
+	linkURL, err := url.Parse(resp.VisitedLink.URL)
+
+	return err == nil && len(linkURL.Query()) > 0
+}
+
+func hasPathParams(resp *Response) bool {
+	linkURL, err := url.Parse(resp.VisitedLink.URL)
+	if err != nil {
+		return false
+	}
+
+	for _, segment := range strings.Split(linkURL.Path, "/") {
+		if segment != "" && segmentPlaceholder(segment) != segment {
+			return true
+		}
+	}
+
+	return false
+}
+
+func hasStatusError(resp *Response) bool {
+	return resp.StatusCode >= 500
+}
+
+func hasJSONBody(resp *Response) bool {
+	return resp.ContentType == "application/json" || strings.HasSuffix(resp.ContentType, "+json")
+}
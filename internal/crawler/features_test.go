@@ -0,0 +1,83 @@
+package crawler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractFeatures(t *testing.T) {
+	tabTests := []struct {
+		name     string
+		link     string
+		status   int
+		header   http.Header
+		body     string
+		expected []string
+	}{
+		{
+			name:     "plain page",
+			link:     fakeLink + "about",
+			status:   http.StatusOK,
+			body:     "<html><body><p>About</p></body></html>",
+			expected: []string{},
+		},
+		{
+			name:     "login & upload forms",
+			link:     fakeLink + "account",
+			status:   http.StatusOK,
+			body:     `<form><input type="password"></form><form enctype="multipart/form-data"><input type="file"></form>`,
+			expected: []string{"form", "login-form", "upload-form"},
+		},
+		{
+			name:     "json api with path & query params",
+			link:     fakeLink + "api/users/42?fields=name",
+			status:   http.StatusOK,
+			header:   http.Header{"Content-Type": []string{"application/json; charset=utf-8"}},
+			body:     `{"name":"user"}`,
+			expected: []string{"json", "path-params", "query-params"},
+		},
+		{
+			name:     "server error",
+			link:     fakeLink + "crash",
+			status:   http.StatusInternalServerError,
+			expected: []string{"status-error"},
+		},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.name, func(t *testing.T) {
+			response := &Response{VisitedLink: NewLink(test.link), StatusCode: test.status, Header: test.header}
+			response.ContentType = mediaTypeOf(test.header.Get("Content-Type"))
+			if test.body != "" {
+				response.BodyForQueries, _ = goquery.NewDocumentFromReader(strings.NewReader(test.body))
+			}
+			response.extractFeatures()
+			require.Equal(t, test.expected, response.Features.Names(), "should be equal")
+		})
+	}
+}
+
+func TestRegisterFeature(t *testing.T) {
+	require.Error(t, RegisterFeature(FeatureForm, hasFormTag), "should fail on duplicate feature")
+	require.Contains(t, RegisteredFeatures(), FeatureJSON, "should list built-in features")
+}
+
+func TestFeatureSetJSON(t *testing.T) {
+	response := new(Response)
+	response.SetFeature(FeatureQueryParams, FeatureForm)
+
+	data, err := json.Marshal(response.Features)
+	require.NoError(t, err, "no error expected")
+	require.JSONEq(t, `["form","query-params"]`, string(data), "should be sorted list of names")
+
+	var decoded FeatureSet
+	require.NoError(t, json.Unmarshal(data, &decoded), "no error expected")
+	require.Equal(t, response.Features, decoded, "should be equal")
+	require.True(t, response.HasAnyFeature(FeatureJSON, FeatureForm), "should have one of features")
+	require.False(t, response.HasAnyFeature(FeatureJSON), "should not have feature")
+}
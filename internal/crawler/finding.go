@@ -0,0 +1,117 @@
+package crawler
+
+import (
+	"net/url"
+	"sort"
+	"sync"
+)
+
+//Severities of findings
+const (
+	SeverityInfo   = "info"
+	SeverityLow    = "low"
+	SeverityMedium = "medium"
+	SeverityHigh   = "high"
+)
+
+//Finding result of a passive check of crawled response, reported to the result collector
+type Finding struct {
+	Type     string //result type, the same for all findings of one check
+	URL      string //url the finding was detected on
+	Scope    string //finding is reported once per scope, e.g. per host or per url
+	Param    string //name of the header, cookie or parameter the finding relates to
+	Payload  string //short description of the issue
+	Evidence string //raw data proving the issue
+	Severity string //one of Severity... constants
+}
+
+//PassiveCheck analyses crawled responses without sending additional requests
+type PassiveCheck interface {
+	Type() string                    //type of findings reported by the check
+	Check(resp *Response) []*Finding //returns findings for a given response
+}
+
+//Findings thread-safe set of findings deduplicated by type, scope, param & payload
+type Findings struct {
+	mu    sync.Mutex
+	keys  map[string]bool
+	list  []*Finding
+	byURL map[string][]*Finding
+}
+
+//NewFindings is a [crawler.Findings] constructor
+func NewFindings() *Findings {
+	return &Findings{keys: map[string]bool{}, byURL: map[string][]*Finding{}}
+}
+
+//Add adds finding to the set, returns false if the same finding is already there
+func (fs *Findings) Add(finding *Finding) bool {
+	key := finding.Type + "\x00" + finding.Scope + "\x00" + finding.Param + "\x00" + finding.Payload
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.keys[key] {
+		return false
+	}
+	fs.keys[key] = true
+	fs.list = append(fs.list, finding)
+	fs.byURL[finding.URL] = append(fs.byURL[finding.URL], finding)
+
+	return true
+}
+
+//All returns all findings in order of adding
+func (fs *Findings) All() []*Finding {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return append([]*Finding(nil), fs.list...)
+}
+
+//ByURL returns findings of a given url in order of adding
+func (fs *Findings) ByURL(link string) []*Finding {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return append([]*Finding(nil), fs.byURL[link]...)
+}
+
+//ByType returns findings of a given type sorted by url
+func (fs *Findings) ByType(findingType string) []*Finding {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	var result []*Finding
+	for _, finding := range fs.list {
+		if finding.Type == findingType {
+			result = append(result, finding)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].URL < result[j].URL
+	})
+
+	return result
+}
+
+func (cr *Crawler) runPassiveChecks(resp *Response) {
+	if cr.Findings == nil {
+		return
+	}
+
+	for _, check := range cr.Checks {
+		for _, finding := range check.Check(resp) {
+			cr.Findings.Add(finding)
+		}
+	}
+}
+
+//hostOf returns host of a given link to be used as a finding scope
+func hostOf(link string) string {
+	parsedURL, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+
+	return parsedURL.Host
+}
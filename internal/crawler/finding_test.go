@@ -0,0 +1,54 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type passiveCheckStub struct{}
+
+func (passiveCheckStub) Type() string {
+	return "stub"
+}
+
+func (check passiveCheckStub) Check(resp *Response) []*Finding {
+	return []*Finding{{Type: check.Type(), URL: resp.VisitedLink.URL, Scope: hostOf(resp.VisitedLink.URL)}}
+}
+
+func TestFindingsAdd(t *testing.T) {
+	findings := NewFindings()
+	finding := &Finding{Type: "stub", URL: fakeLink, Scope: "this.is.link", Payload: "issue"}
+
+	require.True(t, findings.Add(finding), "new finding should be added")
+	require.False(t, findings.Add(&Finding{Type: "stub", URL: fakeLink + "other", Scope: "this.is.link", Payload: "issue"}),
+		"finding with the same scope should be deduplicated")
+	require.True(t, findings.Add(&Finding{Type: "other", URL: fakeLink, Scope: "this.is.link", Payload: "issue"}),
+		"finding of other type should be added")
+	require.Equal(t, []*Finding{finding}, findings.ByType("stub"), "should return findings of a given type")
+	require.Len(t, findings.ByURL(fakeLink), 2, "should return findings of a given url")
+	require.Empty(t, findings.ByURL(fakeLink+"other"), "deduplicated finding should not be indexed")
+}
+
+func TestRunPassiveChecks(t *testing.T) {
+	urlFake, _ := url.Parse(fakeLink)
+	crawler := NewCrawler(context.Background(), urlFake)
+	crawler.client = &httpClientStub{}
+	crawler.Checks = []PassiveCheck{passiveCheckStub{}}
+
+	crawler.ExploreLink(NewLink(fakeLink))
+	crawler.Wait()
+
+	require.Len(t, crawler.Findings.ByType("stub"), 1, "should run passive checks on crawled pages")
+}
+
+func TestRunPassiveChecksWithoutFindings(t *testing.T) {
+	crawler := new(Crawler)
+	crawler.Checks = []PassiveCheck{passiveCheckStub{}}
+	require.NotPanics(t, func() {
+		crawler.runPassiveChecks(&Response{VisitedLink: NewLink(fakeLink), Header: http.Header{}})
+	}, "should skip checks without findings set")
+}
@@ -0,0 +1,109 @@
+package crawler
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+//URLGroup urls sharing the same url pattern & similar page fingerprints
+type URLGroup struct {
+	Pattern     string   //url pattern of the group, see [crawler.URLPattern]
+	Fingerprint uint64   //fingerprint of the first page in the group
+	URLs        []string //all urls of the group
+}
+
+//Simhash returns 64-bit similarity hash of given tokens, similar token sets give hashes with small hamming distance
+func Simhash(tokens []string) uint64 {
+	var weights [64]int
+	for _, token := range tokens {
+		hash := fnv.New64a()
+		_, _ = hash.Write([]byte(token))
+		sum := hash.Sum64()
+		for i := 0; i < 64; i++ {
+			if sum&(1<<i) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+
+	var result uint64
+	for i := 0; i < 64; i++ {
+		if weights[i] > 0 {
+			result |= 1 << i
+		}
+	}
+
+	return result
+}
+
+//HammingDistance returns number of different bits of two fingerprints
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+//fillFingerprint calculates resp.Fingerprint as a simhash of the page DOM shape
+func (resp *Response) fillFingerprint() {
+	queryDoc := resp.BodyForQueries
+	if queryDoc == nil {
+		return
+	}
+
+	var tokens []string
+	queryDoc.Find("*").Each(func(i int, sel *goquery.Selection) {
+		token := goquery.NodeName(sel.Parent()) + ">" + goquery.NodeName(sel)
+		if class, ok := sel.Attr("class"); ok {
+			token += "." + strings.Join(strings.Fields(class), ".")
+		}
+		tokens = append(tokens, token)
+	})
+	resp.Fingerprint = Simhash(tokens)
+}
+
+//GroupSimilar groups responses by url pattern & fingerprints differing in no more than maxDistance bits
+func GroupSimilar(responses []*Response, maxDistance int) []*URLGroup {
+	sorted := make([]*Response, len(responses))
+	copy(sorted, responses)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].VisitedLink.URL < sorted[j].VisitedLink.URL
+	})
+
+	var result []*URLGroup
+	groupsByPattern := map[string][]*URLGroup{}
+	for _, resp := range sorted {
+		pattern := URLPattern(resp.VisitedLink.URL)
+		group := findGroup(groupsByPattern[pattern], resp.Fingerprint, maxDistance)
+		if group == nil {
+			group = &URLGroup{Pattern: pattern, Fingerprint: resp.Fingerprint}
+			groupsByPattern[pattern] = append(groupsByPattern[pattern], group)
+			result = append(result, group)
+		}
+		group.URLs = append(group.URLs, resp.VisitedLink.URL)
+	}
+
+	return result
+}
+
+func findGroup(groups []*URLGroup, fingerprint uint64, maxDistance int) *URLGroup {
+	for _, group := range groups {
+		if HammingDistance(group.Fingerprint, fingerprint) <= maxDistance {
+			return group
+		}
+	}
+
+	return nil
+}
+
+//Representatives returns up to num first urls of the group
+func (group *URLGroup) Representatives(num int) []string {
+	if num >= len(group.URLs) {
+		return group.URLs
+	}
+
+	return group.URLs[:num]
+}
@@ -0,0 +1,47 @@
+package crawler
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/require"
+)
+
+func responseWithBody(link, body string) *Response {
+	queryDoc, _ := goquery.NewDocumentFromReader(ioutil.NopCloser(strings.NewReader(body)))
+	resp := &Response{
+		VisitedLink:    NewLink(link),
+		BodyForQueries: queryDoc,
+	}
+	resp.fillFingerprint()
+
+	return resp
+}
+
+func TestSimhash(t *testing.T) {
+	first := Simhash([]string{"a", "b", "c", "d", "e", "f", "g", "h"})
+	similar := Simhash([]string{"a", "b", "c", "d", "e", "f", "g", "x"})
+	different := Simhash([]string{"1", "2", "3", "4", "5", "6", "7", "8"})
+
+	require.Equal(t, first, Simhash([]string{"a", "b", "c", "d", "e", "f", "g", "h"}), "should be stable")
+	require.Less(t, HammingDistance(first, similar), HammingDistance(first, different), "similar tokens should be closer")
+}
+
+func TestGroupSimilar(t *testing.T) {
+	productBody := "<html><body><div class='product'><h1>%s</h1><p>price</p></div></body></html>"
+	responses := []*Response{
+		responseWithBody(fakeLink+"product?id=2", strings.Replace(productBody, "%s", "Phone", 1)),
+		responseWithBody(fakeLink+"product?id=1", strings.Replace(productBody, "%s", "Laptop", 1)),
+		responseWithBody(fakeLink+"product?id=3", "<html><body><form><input name='q'></form></body></html>"),
+		responseWithBody(fakeLink+"about", strings.Replace(productBody, "%s", "About", 1)),
+	}
+
+	groups := GroupSimilar(responses, 3)
+	require.Len(t, groups, 3, "should group by pattern & fingerprint")
+	require.Equal(t, "/about", groups[0].Pattern, "groups should be sorted by url")
+	require.Equal(t, []string{fakeLink + "product?id=1", fakeLink + "product?id=2"}, groups[1].URLs, "same template pages should be grouped")
+	require.Equal(t, []string{fakeLink + "product?id=1"}, groups[1].Representatives(1), "should return representatives")
+	require.Equal(t, []string{fakeLink + "product?id=3"}, groups[2].URLs, "different page should have own group")
+}
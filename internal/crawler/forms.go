@@ -0,0 +1,119 @@
+package crawler
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+//FindingInsecureForm type of [crawler.InsecureFormCheck] findings
+const FindingInsecureForm = "insecure-form"
+
+//Form html form of a page, see [crawler.Response.Forms]
+type Form struct {
+	Action string   `json:"action"` //absolute url the form is submitted to
+	Method string   `json:"method"` //upper-case http method, GET by default
+	Fields []string `json:"fields"` //sorted unique names of submitted fields
+}
+
+//String returns form signature, e.g. "POST https://site/login (password, user)"
+func (form *Form) String() string {
+	return form.Method + " " + form.Action + " (" + strings.Join(form.Fields, ", ") + ")"
+}
+
+//extractForms fills resp.Forms from resp.BodyForQueries
+func (resp *Response) extractForms() {
+	if resp.BodyForQueries == nil || resp.VisitedLink == nil {
+		return
+	}
+	pageURL, err := url.Parse(resp.VisitedLink.URL)
+	if err != nil {
+		return
+	}
+
+	resp.Forms = nil
+	resp.BodyForQueries.Find("form").Each(func(i int, sel *goquery.Selection) {
+		form := &Form{Action: resp.VisitedLink.URL, Method: strings.ToUpper(strings.TrimSpace(sel.AttrOr("method", "")))}
+		if actionURL, err := pageURL.Parse(strings.TrimSpace(sel.AttrOr("action", ""))); err == nil {
+			actionURL.Fragment = ""
+			form.Action = actionURL.String()
+		}
+		if form.Method == "" {
+			form.Method = http.MethodGet
+		}
+
+		seen := map[string]bool{}
+		sel.Find("input[name], select[name], textarea[name], button[name]").Each(func(i int, field *goquery.Selection) {
+			if name := field.AttrOr("name", ""); name != "" && !seen[name] {
+				seen[name] = true
+				form.Fields = append(form.Fields, name)
+			}
+		})
+		sort.Strings(form.Fields)
+		resp.Forms = append(resp.Forms, form)
+	})
+}
+
+//InsecureFormCheck passive check for forms submitted over HTTP & password fields exposed to interception or autofill
+type InsecureFormCheck struct{}
+
+//Type returns type of the check findings
+func (InsecureFormCheck) Type() string {
+	return FindingInsecureForm
+}
+
+//Check returns findings for insecure forms of a given response page
+func (check InsecureFormCheck) Check(resp *Response) []*Finding {
+	if resp.BodyForQueries == nil || resp.VisitedLink == nil {
+		return nil
+	}
+	pageURL, err := url.Parse(resp.VisitedLink.URL)
+	if err != nil {
+		return nil
+	}
+
+	var result []*Finding
+	addFinding := func(param, payload, severity string, sel *goquery.Selection) {
+		result = append(result, &Finding{
+			Type:     check.Type(),
+			URL:      resp.VisitedLink.URL,
+			Scope:    resp.VisitedLink.URL,
+			Param:    param,
+			Payload:  payload,
+			Evidence: outerHTML(sel),
+			Severity: severity,
+		})
+	}
+
+	resp.BodyForQueries.Find("form").Each(func(i int, form *goquery.Selection) {
+		actionURL, err := pageURL.Parse(strings.TrimSpace(form.AttrOr("action", "")))
+		if err == nil && pageURL.Scheme == "https" && actionURL.Scheme == "http" {
+			addFinding(actionURL.String(), "form submitted over HTTP from HTTPS page", SeverityMedium, form)
+		}
+	})
+
+	resp.BodyForQueries.Find(`input[type="password" i]`).Each(func(i int, input *goquery.Selection) {
+		name := input.AttrOr("name", input.AttrOr("id", "password"))
+		if pageURL.Scheme == "http" {
+			addFinding(name, "password field on HTTP page", SeverityHigh, input)
+		}
+		if autocompleteEnabled(input) {
+			addFinding(name, "autocomplete enabled on password field", SeverityInfo, input)
+		}
+	})
+
+	return result
+}
+
+//autocompleteEnabled returns false if autocomplete is turned off for the input or for its form
+func autocompleteEnabled(input *goquery.Selection) bool {
+	value, ok := input.Attr("autocomplete")
+	if !ok {
+		value = input.Closest("form").AttrOr("autocomplete", "on")
+	}
+
+	return strings.ToLower(strings.TrimSpace(value)) != "off"
+}
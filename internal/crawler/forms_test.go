@@ -0,0 +1,72 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInsecureFormCheck(t *testing.T) {
+	tabTests := []struct {
+		name     string
+		link     string
+		page     string
+		expected []string
+	}{
+		{
+			name:     "secure login form",
+			link:     fakeLink,
+			page:     `<form action="/login" method="post"><input name="user"><input type="password" name="pass" autocomplete="off"></form>`,
+			expected: nil,
+		},
+		{
+			name: "login form posting to http",
+			link: fakeLink,
+			page: `<form action="http://this.is.link/login" method="post" autocomplete="off"><input type="PASSWORD" name="pass"></form>`,
+			expected: []string{
+				"form submitted over HTTP from HTTPS page",
+			},
+		},
+		{
+			name: "password on http page with autocomplete",
+			link: "http://this.is.link/login",
+			page: `<form method="post"><input type="password" name="pass"></form>`,
+			expected: []string{
+				"password field on HTTP page",
+				"autocomplete enabled on password field",
+			},
+		},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.name, func(t *testing.T) {
+			queryDoc, _ := goquery.NewDocumentFromReader(strings.NewReader(test.page))
+			response := &Response{VisitedLink: NewLink(test.link), BodyForQueries: queryDoc}
+
+			var got []string
+			for _, finding := range (InsecureFormCheck{}).Check(response) {
+				got = append(got, finding.Payload)
+			}
+			require.Equal(t, test.expected, got, "should be equal")
+		})
+	}
+}
+
+func TestExtractForms(t *testing.T) {
+	resp := NewResponse(NewLink(fakeLink+"account/"), 200)
+	require.NoError(t, resp.FillResponseBody(strings.NewReader(`
+		<form action="/login#top" method="post">
+			<input name="user"><input type="password" name="password"><input name="user"><button>go</button>
+		</form>
+		<form><select name="sort"></select><textarea name="q"></textarea></form>`)), "no error expected")
+
+	resp.extractForms()
+
+	require.Equal(t, []*Form{
+		{Action: fakeLink + "login", Method: "POST", Fields: []string{"password", "user"}},
+		{Action: fakeLink + "account/", Method: "GET", Fields: []string{"q", "sort"}},
+	}, resp.Forms, "should resolve action, default method & collect unique field names")
+	require.Equal(t, "POST https://this.is.link/login (password, user)", resp.Forms[0].String(), "should format signature")
+}
@@ -0,0 +1,221 @@
+package crawler
+
+import (
+	"container/heap"
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+//Names of frontier prioritizers, see [crawler.NewPrioritizer]
+const (
+	PriorityBreadthFirst  = "bfs"            //links are visited by depth, in order of finding within one depth
+	PriorityAttackSurface = "attack-surface" //links with query params or forms are preferred, static assets are visited last
+)
+
+//Prioritizer orders links of the frontier
+type Prioritizer interface {
+	Priority(link *Link) int //links with lower priority are visited first, links with equal priority in order of finding
+}
+
+//NewPrioritizer returns prioritizer of a given name, empty name means PriorityBreadthFirst
+func NewPrioritizer(name string) (Prioritizer, error) {
+	switch name {
+	case "", PriorityBreadthFirst:
+		return BreadthFirst{}, nil
+	case PriorityAttackSurface:
+		return AttackSurfaceFirst{}, nil
+	default:
+		return nil, fmt.Errorf("unknown frontier priority %q", name)
+	}
+}
+
+//BreadthFirst prioritizer visiting shallow links first
+type BreadthFirst struct{}
+
+//Priority returns depth of the link
+func (BreadthFirst) Priority(link *Link) int {
+	return link.Jumps
+}
+
+//staticAssetExtensions extensions of files without links or attack surface
+var staticAssetExtensions = map[string]bool{
+	".css": true, ".js": true, ".map": true, ".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".svg": true,
+	".ico": true, ".webp": true, ".bmp": true, ".woff": true, ".woff2": true, ".ttf": true, ".eot": true, ".otf": true,
+	".mp3": true, ".mp4": true, ".webm": true, ".avi": true, ".pdf": true, ".zip": true, ".gz": true,
+}
+
+//formPathRgx matches path segments of pages usually having forms
+var formPathRgx = regexp.MustCompile(`(?i)(^|[/_.-])(log-?in|sign-?in|sign-?up|register|auth|search|contact|comment|feedback|upload|checkout|subscribe|account|profile|password|reset)([/_.-]|$)`)
+
+//assetPriorityShift moves static assets behind pages of any allowed depth
+const assetPriorityShift = 1 << 20
+
+//AttackSurfaceFirst prioritizer visiting shallow links first, a link with query params or a path of a page usually having forms
+//goes together with links one level shallower, & static assets go after all other links
+type AttackSurfaceFirst struct{}
+
+//Priority returns doubled depth of the link lowered by its attack surface hints
+func (AttackSurfaceFirst) Priority(link *Link) int {
+	result := 2 * link.Jumps
+	parsedURL, err := url.Parse(link.URL)
+	if err != nil {
+		return result
+	}
+	if staticAssetExtensions[strings.ToLower(path.Ext(parsedURL.Path))] {
+		return result + assetPriorityShift
+	}
+	if parsedURL.RawQuery != "" {
+		result--
+	}
+	if formPathRgx.MatchString(parsedURL.Path) {
+		result--
+	}
+
+	return result
+}
+
+//Frontier queue of links found but not visited yet, ordered by its prioritizer
+type Frontier struct {
+	mu          sync.Mutex
+	cond        *sync.Cond
+	prioritizer Prioritizer
+	queue       frontierQueue
+	seq         int              //order of finding of the next link
+	links       map[string]*Link //links queued or being visited by url, see [crawler.Crawler.Checkpoint]
+	pending     int              //number of links queued or being visited
+	closed      bool
+}
+
+//NewFrontier is a [crawler.Frontier] constructor, nil prioritizer means [crawler.BreadthFirst]
+func NewFrontier(prioritizer Prioritizer) *Frontier {
+	if prioritizer == nil {
+		prioritizer = BreadthFirst{}
+	}
+	result := &Frontier{prioritizer: prioritizer, links: map[string]*Link{}}
+	result.cond = sync.NewCond(&result.mu)
+
+	return result
+}
+
+//Push queues given links skipping ones already in the frontier, returns number of queued links
+func (f *Frontier) Push(links ...*Link) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.push(links)
+}
+
+func (f *Frontier) push(links []*Link) int {
+	var result int
+	for _, l := range links {
+		if _, ok := f.links[l.URL]; ok || f.closed {
+			continue
+		}
+		f.links[l.URL] = l
+		heap.Push(&f.queue, &frontierItem{link: l, priority: f.prioritizer.Priority(l), seq: f.seq})
+		f.seq++
+		f.pending++
+		result++
+	}
+	if result > 0 {
+		f.cond.Broadcast()
+	}
+
+	return result
+}
+
+//Pop returns the link with the lowest priority, waits while the frontier is empty, returns false if the frontier is closed
+func (f *Frontier) Pop() (*Link, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for f.queue.Len() == 0 && !f.closed {
+		f.cond.Wait()
+	}
+	if f.closed {
+		return nil, false
+	}
+
+	return heap.Pop(&f.queue).(*frontierItem).link, true
+}
+
+//Done marks a popped link as visited & queues its children, the link is kept in the frontier if it's not visited because of crawler exit
+//children are queued with the link removal, so [crawler.Frontier.Links] never loses them
+func (f *Frontier) Done(link *Link, keep bool, children []*Link) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.push(children)
+	if !keep {
+		delete(f.links, link.URL)
+	}
+	f.pending--
+	if f.pending == 0 {
+		f.cond.Broadcast()
+	}
+}
+
+//Wait waits until all queued links are done
+func (f *Frontier) Wait() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for f.pending > 0 && !f.closed {
+		f.cond.Wait()
+	}
+}
+
+//Close wakes up waiting [crawler.Frontier.Pop] calls, links pushed after closing are dropped
+func (f *Frontier) Close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	f.cond.Broadcast()
+}
+
+//Links returns copies of queued links & links being visited
+func (f *Frontier) Links() []*Link {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	result := make([]*Link, 0, len(f.links))
+	for _, l := range f.links {
+		linkCopy := *l
+		result = append(result, &linkCopy)
+	}
+
+	return result
+}
+
+type frontierItem struct {
+	link     *Link
+	priority int
+	seq      int
+}
+
+//frontierQueue min-heap of links by priority & order of finding
+type frontierQueue []*frontierItem
+
+func (q frontierQueue) Len() int { return len(q) }
+
+func (q frontierQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority < q[j].priority
+	}
+
+	return q[i].seq < q[j].seq
+}
+
+func (q frontierQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *frontierQueue) Push(x any) { *q = append(*q, x.(*frontierItem)) }
+
+func (q *frontierQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+
+	return item
+}
@@ -0,0 +1,129 @@
+package crawler
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttackSurfaceFirst(t *testing.T) {
+	tabTests := []struct {
+		link     *Link
+		expected int
+	}{
+		{link: NewLink(fakeLink+"about", 1), expected: 2},
+		{link: NewLink(fakeLink+"items?id=1", 1), expected: 1},
+		{link: NewLink(fakeLink+"login", 1), expected: 1},
+		{link: NewLink(fakeLink+"user-search?q=1", 2), expected: 2},
+		{link: NewLink(fakeLink+"logo.PNG?v=2", 0), expected: assetPriorityShift},
+		{link: NewLink(fakeLink+"catalog", 0), expected: 0},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.link.URL, func(t *testing.T) {
+			require.Equal(t, test.expected, AttackSurfaceFirst{}.Priority(test.link), "should be equal")
+		})
+	}
+}
+
+func TestNewPrioritizer(t *testing.T) {
+	prioritizer, err := NewPrioritizer("")
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, BreadthFirst{}, prioritizer, "should be breadth first by default")
+	prioritizer, err = NewPrioritizer(PriorityAttackSurface)
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, AttackSurfaceFirst{}, prioritizer, "should be equal")
+	_, err = NewPrioritizer("random")
+	require.Error(t, err, "error expected on unknown name")
+}
+
+func TestFrontier(t *testing.T) {
+	frontier := NewFrontier(nil)
+	require.Equal(t, 3, frontier.Push(NewLink(fakeLink+"b", 2), NewLink(fakeLink+"a", 1), NewLink(fakeLink+"c", 2), NewLink(fakeLink+"a", 1)),
+		"should skip links already in the frontier")
+
+	link, ok := frontier.Pop()
+	require.True(t, ok, "should pop queued link")
+	require.Equal(t, fakeLink+"a", link.URL, "shallow link should go first")
+	frontier.Done(link, false, []*Link{NewLink(fakeLink+"a/1", 2)})
+	var popped []string
+	for i := 0; i < 3; i++ {
+		link, _ = frontier.Pop()
+		popped = append(popped, link.URL)
+	}
+	require.Equal(t, []string{fakeLink + "b", fakeLink + "c", fakeLink + "a/1"}, popped, "links of one depth should go in order of finding")
+
+	frontier.Done(&Link{URL: fakeLink + "b"}, true, nil)
+	frontier.Done(&Link{URL: fakeLink + "c"}, false, nil)
+	require.ElementsMatch(t, []*Link{NewLink(fakeLink+"b", 2), NewLink(fakeLink+"a/1", 2)}, frontier.Links(),
+		"should keep links being visited & links not visited on exit")
+	frontier.Done(&Link{URL: fakeLink + "a/1"}, false, nil)
+	frontier.Wait()
+
+	frontier.Close()
+	_, ok = frontier.Pop()
+	require.False(t, ok, "closed frontier should not return links")
+	require.Zero(t, frontier.Push(NewLink(fakeLink+"d")), "closed frontier should drop links")
+}
+
+func TestFrontierInFlightDuplicate(t *testing.T) {
+	frontier := NewFrontier(nil)
+	frontier.Push(NewLink(fakeLink + "a"))
+	link, _ := frontier.Pop()
+
+	require.Zero(t, frontier.Push(NewLink(fakeLink+"a")), "link being visited should not be queued again")
+	require.Equal(t, []*Link{NewLink(fakeLink + "a")}, frontier.Links(), "duplicate should not drop link being visited")
+
+	frontier.Done(link, true, nil)
+	require.Equal(t, []*Link{NewLink(fakeLink + "a")}, frontier.Links(), "should keep link not visited on exit for checkpoint")
+}
+
+func TestExploreLinkOrder(t *testing.T) {
+	urlFake, _ := url.Parse(fakeLink)
+	pages := map[string]string{
+		fakeLink:                "<a href='/a'>a</a><a href='/b'>b</a>",
+		fakeLink + "a":          "<a href='/a/1'>a1</a><link href='/style.css'><a href='/search?q=1'>search</a>",
+		fakeLink + "b":          "<a href='/b/1'>b1</a>",
+		fakeLink + "a/1":        "a1",
+		fakeLink + "b/1":        "b1",
+		fakeLink + "search?q=1": "search",
+		fakeLink + "style.css":  "css",
+	}
+
+	tabTests := []struct {
+		name        string
+		prioritizer Prioritizer
+		expected    []string
+	}{
+		{
+			name:        "breadth first",
+			prioritizer: BreadthFirst{},
+			expected:    []string{"", "a", "b", "a/1", "style.css", "search?q=1", "b/1"},
+		},
+		{
+			name:        "attack surface first",
+			prioritizer: AttackSurfaceFirst{},
+			expected:    []string{"", "a", "b", "search?q=1", "a/1", "b/1", "style.css"},
+		},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.name, func(t *testing.T) {
+			crawler := NewCrawler(context.Background(), urlFake)
+			crawler.MaxJumps = 3
+			crawler.SetPrioritizer(test.prioritizer)
+			client := &httpClientInterruptStub{pages: pages, cancel: func() {}}
+			crawler.client = client
+			crawler.ExploreLink(NewLink(fakeLink))
+			crawler.Wait()
+
+			expected := make([]string, 0, len(test.expected))
+			for _, path := range test.expected {
+				expected = append(expected, fakeLink+path)
+			}
+			require.Equal(t, expected, client.requested, "should visit links in order of priority")
+		})
+	}
+}
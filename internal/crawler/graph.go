@@ -0,0 +1,225 @@
+package crawler
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//Extractors of links without html element, see [crawler.Link.Extractor]
+const (
+	ExtractorRedirect = "redirect" //Location of a redirect response
+	ExtractorSeed     = "seed"     //link given to [crawler.Crawler.Seed]
+)
+
+//Formats of site graph export
+const (
+	GraphFormatDOT     = "dot"
+	GraphFormatGraphML = "graphml"
+	GraphFormatJSON    = "json"
+)
+
+//Edge link from a parent page to a child url
+type Edge struct {
+	From      string `json:"from"`      //url of the page containing the link
+	To        string `json:"to"`        //url the link points to
+	Extractor string `json:"extractor"` //how the link was found, e.g. "a[href]", "script[src]", "redirect"
+}
+
+//GraphNode url of the site graph
+type GraphNode struct {
+	URL    string `json:"url"`              //node url
+	Status int    `json:"status,omitempty"` //http status of the visited url, 0 - not visited
+}
+
+//SiteGraph thread-safe graph of urls found during the crawl
+type SiteGraph struct {
+	mu    sync.Mutex
+	nodes map[string]int
+	edges map[Edge]bool
+}
+
+type graphJSON struct {
+	Nodes []*GraphNode `json:"nodes"`
+	Edges []*Edge      `json:"edges"`
+}
+
+//NewSiteGraph is a [crawler.SiteGraph] constructor
+func NewSiteGraph() *SiteGraph {
+	return &SiteGraph{nodes: map[string]int{}, edges: map[Edge]bool{}}
+}
+
+//AddNode adds a visited url with its http status to the graph
+func (g *SiteGraph) AddNode(link string, status int) {
+	if g == nil {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.nodes[link] = status
+}
+
+//AddEdge adds a link between two urls, urls not visited yet are added as nodes without status
+func (g *SiteGraph) AddEdge(from, to, extractor string) {
+	if g == nil || from == "" || to == "" {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.edges[Edge{From: from, To: to, Extractor: extractor}] = true
+	for _, link := range []string{from, to} {
+		if _, ok := g.nodes[link]; !ok {
+			g.nodes[link] = 0
+		}
+	}
+}
+
+//Nodes returns graph nodes sorted by url
+func (g *SiteGraph) Nodes() []*GraphNode {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	result := make([]*GraphNode, 0, len(g.nodes))
+	for link, status := range g.nodes {
+		result = append(result, &GraphNode{URL: link, Status: status})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].URL < result[j].URL
+	})
+
+	return result
+}
+
+//Edges returns graph edges sorted by parent url, child url & extractor
+func (g *SiteGraph) Edges() []*Edge {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	result := make([]*Edge, 0, len(g.edges))
+	for edge := range g.edges {
+		edge := edge
+		result = append(result, &edge)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].From != result[j].From {
+			return result[i].From < result[j].From
+		}
+		if result[i].To != result[j].To {
+			return result[i].To < result[j].To
+		}
+
+		return result[i].Extractor < result[j].Extractor
+	})
+
+	return result
+}
+
+//Write exports the graph in a given format, one of GraphFormat... constants
+func (g *SiteGraph) Write(w io.Writer, format string) error {
+	switch format {
+	case GraphFormatDOT:
+		return g.WriteDOT(w)
+	case GraphFormatGraphML:
+		return g.WriteGraphML(w)
+	case GraphFormatJSON:
+		return g.WriteJSON(w)
+	default:
+		return fmt.Errorf("unknown graph format %q", format)
+	}
+}
+
+//WriteDOT exports the graph in Graphviz DOT format
+func (g *SiteGraph) WriteDOT(w io.Writer) error {
+	var sb strings.Builder
+	sb.WriteString("digraph site {\n")
+	for _, node := range g.Nodes() {
+		label := node.URL
+		if node.Status != 0 {
+			label = fmt.Sprintf("%s\\n%d", node.URL, node.Status)
+		}
+		fmt.Fprintf(&sb, "  %s [label=%s];\n", dotQuote(node.URL), dotQuote(label))
+	}
+	for _, edge := range g.Edges() {
+		fmt.Fprintf(&sb, "  %s -> %s [label=%s];\n", dotQuote(edge.From), dotQuote(edge.To), dotQuote(edge.Extractor))
+	}
+	sb.WriteString("}\n")
+
+	_, err := io.WriteString(w, sb.String())
+
+	return err
+}
+
+//WriteGraphML exports the graph in GraphML format
+func (g *SiteGraph) WriteGraphML(w io.Writer) error {
+	type data struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+	type node struct {
+		ID   string `xml:"id,attr"`
+		Data []data `xml:"data"`
+	}
+	type edge struct {
+		Source string `xml:"source,attr"`
+		Target string `xml:"target,attr"`
+		Data   []data `xml:"data"`
+	}
+	type key struct {
+		ID   string `xml:"id,attr"`
+		For  string `xml:"for,attr"`
+		Name string `xml:"attr.name,attr"`
+		Type string `xml:"attr.type,attr"`
+	}
+	type graph struct {
+		EdgeDefault string `xml:"edgedefault,attr"`
+		Nodes       []node `xml:"node"`
+		Edges       []edge `xml:"edge"`
+	}
+	type graphML struct {
+		XMLName xml.Name `xml:"graphml"`
+		XMLNS   string   `xml:"xmlns,attr"`
+		Keys    []key    `xml:"key"`
+		Graph   graph    `xml:"graph"`
+	}
+
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []key{
+			{ID: "status", For: "node", Name: "status", Type: "int"},
+			{ID: "extractor", For: "edge", Name: "extractor", Type: "string"},
+		},
+		Graph: graph{EdgeDefault: "directed"},
+	}
+	for _, n := range g.Nodes() {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, node{ID: n.URL, Data: []data{{Key: "status", Value: fmt.Sprint(n.Status)}}})
+	}
+	for _, e := range g.Edges() {
+		doc.Graph.Edges = append(doc.Graph.Edges, edge{Source: e.From, Target: e.To, Data: []data{{Key: "extractor", Value: e.Extractor}}})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	return encoder.Encode(doc)
+}
+
+//WriteJSON exports the graph as json object with nodes & edges lists
+func (g *SiteGraph) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(&graphJSON{Nodes: g.Nodes(), Edges: g.Edges()})
+}
+
+func dotQuote(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
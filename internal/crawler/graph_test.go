@@ -0,0 +1,85 @@
+package crawler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSiteGraphRecording(t *testing.T) {
+	urlFake, _ := url.Parse(fakeLink)
+	crawler := NewCrawler(context.Background(), urlFake)
+	crawler.SetNumberOfThreads(2)
+	crawler.MaxJumps = 2
+	crawler.Graph = NewSiteGraph()
+	crawler.client = &httpClientSiteStub{pages: map[string]string{
+		fakeLink:          "<a href='/about'>about</a><script src='/app.js'></script><a href='https://other.site/'>out</a>",
+		fakeLink + "about": "<a href='/'>home</a>",
+		fakeLink + "app.js": "var x = 1;",
+	}}
+
+	crawler.ExploreLink(NewLink(fakeLink))
+	time.Sleep(10 * time.Millisecond)
+	crawler.Wait()
+
+	require.Equal(t, []*Edge{
+		{From: fakeLink, To: "https://other.site/", Extractor: "a[href]"},
+		{From: fakeLink, To: fakeLink + "about", Extractor: "a[href]"},
+		{From: fakeLink, To: fakeLink + "app.js", Extractor: "script[src]"},
+		{From: fakeLink + "about", To: fakeLink, Extractor: "a[href]"},
+	}, crawler.Graph.Edges(), "should record edges with extractors")
+	require.Equal(t, []*GraphNode{
+		{URL: "https://other.site/"},
+		{URL: fakeLink, Status: 200},
+		{URL: fakeLink + "about", Status: 200},
+		{URL: fakeLink + "app.js", Status: 200},
+	}, crawler.Graph.Nodes(), "should record visited & found nodes")
+}
+
+func TestSiteGraphWrite(t *testing.T) {
+	graph := NewSiteGraph()
+	graph.AddNode(fakeLink, 200)
+	graph.AddEdge(fakeLink, fakeLink+"a?q=\"x\"", "a[href]")
+
+	tabTests := []struct {
+		format   string
+		contains []string
+	}{
+		{
+			format:   GraphFormatDOT,
+			contains: []string{"digraph site {", `"https://this.is.link/" [label="https://this.is.link/\n200"];`, `"https://this.is.link/" -> "https://this.is.link/a?q=\"x\"" [label="a[href]"];`},
+		},
+		{
+			format:   GraphFormatGraphML,
+			contains: []string{`<graph edgedefault="directed">`, `<node id="https://this.is.link/">`, `<data key="extractor">a[href]</data>`},
+		},
+		{
+			format:   GraphFormatJSON,
+			contains: []string{`"from": "https://this.is.link/"`, `"status": 200`},
+		},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.format, func(t *testing.T) {
+			buf := new(bytes.Buffer)
+			require.NoError(t, graph.Write(buf, test.format), "no error expected")
+			for _, part := range test.contains {
+				require.Contains(t, buf.String(), part, "should contain")
+			}
+		})
+	}
+
+	require.Error(t, graph.Write(new(bytes.Buffer), "svg"), "should fail on unknown format")
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, graph.WriteJSON(buf), "no error expected")
+	var decoded graphJSON
+	require.NoError(t, json.NewDecoder(strings.NewReader(buf.String())).Decode(&decoded), "should be valid json")
+	require.Len(t, decoded.Edges, 1, "should be equal")
+}
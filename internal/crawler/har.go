@@ -0,0 +1,282 @@
+package crawler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+//HARVersion version of HAR format written by [crawler.HARRecorder]
+const HARVersion = "1.2"
+
+//HARSessionHeaders request headers of HAR entries replayed by a crawl seeded from HAR, see [crawler.HAR.SessionHeaders]
+var HARSessionHeaders = []string{"Cookie", "Authorization"}
+
+//HAR http archive, see http://www.softwareishard.com/blog/har-12-spec/
+type HAR struct {
+	Log *HARLog `json:"log"`
+}
+
+//HARLog root of http archive
+type HARLog struct {
+	Version string      `json:"version"`
+	Creator *HARCreator `json:"creator"`
+	Entries []*HAREntry `json:"entries"`
+	Comment string      `json:"comment,omitempty"`
+}
+
+//HARCreator application that created the archive
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+//HAREntry single request & its response
+type HAREntry struct {
+	StartedDateTime time.Time    `json:"startedDateTime"`
+	Time            float64      `json:"time"` //total time in milliseconds
+	Request         *HARRequest  `json:"request"`
+	Response        *HARResponse `json:"response"`
+	Cache           struct{}     `json:"cache"`
+	Timings         *HARTimings  `json:"timings"`
+}
+
+//HARRequest request of a HAR entry
+type HARRequest struct {
+	Method      string          `json:"method"`
+	URL         string          `json:"url"`
+	HTTPVersion string          `json:"httpVersion"`
+	Cookies     []*HARNameValue `json:"cookies"`
+	Headers     []*HARNameValue `json:"headers"`
+	QueryString []*HARNameValue `json:"queryString"`
+	HeadersSize int             `json:"headersSize"`
+	BodySize    int             `json:"bodySize"`
+}
+
+//HARResponse response of a HAR entry
+type HARResponse struct {
+	Status      int             `json:"status"`
+	StatusText  string          `json:"statusText"`
+	HTTPVersion string          `json:"httpVersion"`
+	Cookies     []*HARNameValue `json:"cookies"`
+	Headers     []*HARNameValue `json:"headers"`
+	Content     *HARContent     `json:"content"`
+	RedirectURL string          `json:"redirectURL"`
+	HeadersSize int             `json:"headersSize"`
+	BodySize    int             `json:"bodySize"`
+}
+
+//HARContent response body
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+//HARTimings request phases durations in milliseconds, -1 if not measured
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+//HARNameValue header, cookie or query parameter
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+//HARRecorder thread-safe recorder of crawler requests & responses, entries are kept in memory until written
+type HARRecorder struct {
+	MaxBodyBytes  int //max number of body bytes of a single response to store in the archive, the rest is truncated
+	MaxTotalBytes int //max number of body bytes of all responses to store in the archive, later bodies are omitted
+	mu            sync.Mutex
+	entries       []*HAREntry
+	totalBytes    int
+	omitted       int
+}
+
+//NewHARRecorder is a [crawler.HARRecorder] constructor
+func NewHARRecorder(maxBodyBytes, maxTotalBytes int) *HARRecorder {
+	return &HARRecorder{MaxBodyBytes: maxBodyBytes, MaxTotalBytes: maxTotalBytes}
+}
+
+//record adds an entry for a given request & response, body is the part of response body read by the crawler
+func (rec *HARRecorder) record(req *http.Request, resp *http.Response, body []byte, started time.Time, wait, receive time.Duration) {
+	if rec == nil {
+		return
+	}
+
+	content := &HARContent{Size: len(body), MimeType: resp.Header.Get("Content-Type")}
+	if rec.MaxBodyBytes > 0 && len(body) > rec.MaxBodyBytes {
+		body = body[:rec.MaxBodyBytes]
+		content.Comment = fmt.Sprintf("truncated to %d bytes", rec.MaxBodyBytes)
+	}
+
+	entry := &HAREntry{
+		StartedDateTime: started,
+		Time:            milliseconds(wait + receive),
+		Request: &HARRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []*HARNameValue{},
+			Headers:     harHeaders(req.Header),
+			QueryString: harQuery(req.URL),
+			HeadersSize: -1,
+			BodySize:    0,
+		},
+		Response: &HARResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     []*HARNameValue{},
+			Headers:     harHeaders(resp.Header),
+			Content:     content,
+			RedirectURL: resp.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    -1,
+		},
+		Timings: &HARTimings{Send: 0, Wait: milliseconds(wait), Receive: milliseconds(receive)},
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.MaxTotalBytes > 0 && rec.totalBytes+len(body) > rec.MaxTotalBytes {
+		content.Comment = fmt.Sprintf("omitted, archive bodies limit of %d bytes is reached", rec.MaxTotalBytes)
+		rec.omitted++
+	} else {
+		content.Text = string(body)
+		rec.totalBytes += len(body)
+	}
+	rec.entries = append(rec.entries, entry)
+}
+
+//HAR returns recorded entries as http archive
+func (rec *HARRecorder) HAR() *HAR {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+
+	entries := make([]*HAREntry, len(rec.entries))
+	copy(entries, rec.entries)
+	var comment string
+	if rec.omitted > 0 {
+		comment = fmt.Sprintf("bodies of %d responses are omitted, archive bodies limit of %d bytes is reached",
+			rec.omitted, rec.MaxTotalBytes)
+	}
+
+	return &HAR{Log: &HARLog{
+		Version: HARVersion,
+		Creator: &HARCreator{Name: "parabellum.crawler", Version: HARVersion},
+		Entries: entries,
+		Comment: comment,
+	}}
+}
+
+//WriteHAR writes recorded entries as http archive into a given file, creating its directory if needed,
+//the file is readable by owner only as it holds session headers, e.g. Cookie & Authorization
+func (rec *HARRecorder) WriteHAR(path string) error {
+	data, err := json.MarshalIndent(rec.HAR(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding HAR: %w", err)
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating HAR directory: %w", err)
+	}
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("error writing HAR: %w", err)
+	}
+
+	return nil
+}
+
+//LoadHAR reads http archive from a given file
+func LoadHAR(path string) (*HAR, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading HAR: %w", err)
+	}
+
+	har := new(HAR)
+	if err = json.Unmarshal(data, har); err != nil {
+		return nil, fmt.Errorf("error parsing HAR: %w", err)
+	}
+	if har.Log == nil {
+		return nil, fmt.Errorf("error parsing HAR: no log")
+	}
+
+	return har, nil
+}
+
+//SeedLinks returns urls of GET requests of the archive in order of their appearance
+func (har *HAR) SeedLinks() []*Link {
+	var result []*Link
+	seen := map[string]bool{}
+	for _, entry := range har.Log.Entries {
+		if entry.Request == nil || entry.Request.Method != http.MethodGet || seen[entry.Request.URL] {
+			continue
+		}
+		seen[entry.Request.URL] = true
+		result = append(result, NewLink(entry.Request.URL))
+	}
+
+	return result
+}
+
+//SessionHeaders returns the latest values of HARSessionHeaders sent to a given host in the archive
+func (har *HAR) SessionHeaders(host string) http.Header {
+	result := http.Header{}
+	for _, entry := range har.Log.Entries {
+		if entry.Request == nil || hostOf(entry.Request.URL) != host {
+			continue
+		}
+		for _, header := range entry.Request.Headers {
+			for _, name := range HARSessionHeaders {
+				if http.CanonicalHeaderKey(header.Name) == name {
+					result.Set(name, header.Value)
+				}
+			}
+		}
+	}
+
+	return result
+}
+
+func harHeaders(header http.Header) []*HARNameValue {
+	result := []*HARNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			result = append(result, &HARNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+func harQuery(link *url.URL) []*HARNameValue {
+	result := []*HARNameValue{}
+	for name, values := range link.Query() {
+		for _, value := range values {
+			result = append(result, &HARNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+func milliseconds(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
@@ -0,0 +1,85 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHARRecorder(t *testing.T) {
+	urlFake, _ := url.Parse(fakeLink)
+	crawler := NewCrawler(context.Background(), urlFake)
+	crawler.client = &httpClientRedirectStub{redirects: map[string]string{fakeLink + "old": "/new"}}
+	crawler.Headers = http.Header{"Cookie": []string{"session=abc"}}
+	crawler.Recorder = NewHARRecorder(10, 0)
+
+	_, err := crawler.makeGetRequest(NewLink(fakeLink + "old?lang=en"))
+	require.NoError(t, err, "no error expected")
+	_, err = crawler.makeGetRequest(NewLink(fakeLink + "old"))
+	require.NoError(t, err, "no error expected")
+
+	harPath := filepath.Join(t.TempDir(), "task", "task.har")
+	require.NoError(t, crawler.Recorder.WriteHAR(harPath), "no error expected")
+	info, err := os.Stat(harPath)
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm(), "HAR with session headers should be readable by owner only")
+	har, err := LoadHAR(harPath)
+	require.NoError(t, err, "should read written HAR")
+
+	entries := har.Log.Entries
+	require.Equal(t, HARVersion, har.Log.Version, "should be equal")
+	require.Len(t, entries, 3, "should record every redirect hop")
+	require.Equal(t, fakeLink+"old", entries[1].Request.URL, "should be equal")
+	require.Equal(t, http.StatusFound, entries[1].Response.Status, "should be equal")
+	require.Equal(t, "/new", entries[1].Response.RedirectURL, "should be equal")
+	require.Equal(t, fakeLink+"new", entries[2].Request.URL, "should be equal")
+	require.Equal(t, "<html><bo", entries[2].Response.Content.Text[:9], "should store body")
+	require.Len(t, entries[2].Response.Content.Text, 10, "should truncate body")
+	require.NotEmpty(t, entries[2].Response.Content.Comment, "should mark truncated body")
+	require.Equal(t, []*HARNameValue{{Name: "Cookie", Value: "session=abc"}}, entries[2].Request.Headers, "should be equal")
+	require.Empty(t, har.Log.Comment, "no bodies should be omitted without archive limit")
+
+	require.Equal(t, []*Link{NewLink(fakeLink + "old"), NewLink(fakeLink + "new")}, har.SeedLinks()[1:], "should be unique GET urls")
+	require.Equal(t, http.Header{"Cookie": []string{"session=abc"}}, har.SessionHeaders("this.is.link"), "should be equal")
+	require.Empty(t, har.SessionHeaders("other.site"), "should return headers of given host only")
+}
+
+func TestHARRecorderTotalBytes(t *testing.T) {
+	urlFake, _ := url.Parse(fakeLink)
+	crawler := NewCrawler(context.Background(), urlFake)
+	crawler.client = &httpClientRedirectStub{}
+	crawler.Recorder = NewHARRecorder(10, 15)
+
+	for _, link := range []string{fakeLink + "a", fakeLink + "b"} {
+		_, err := crawler.makeGetRequest(NewLink(link))
+		require.NoError(t, err, "no error expected")
+	}
+
+	har := crawler.Recorder.HAR()
+	require.Len(t, har.Log.Entries, 2, "should record entries above archive limit")
+	require.Len(t, har.Log.Entries[0].Response.Content.Text, 10, "should store body under archive limit")
+	require.Empty(t, har.Log.Entries[1].Response.Content.Text, "should omit body above archive limit")
+	require.NotEmpty(t, har.Log.Entries[1].Response.Content.Comment, "should mark omitted body")
+	require.Contains(t, har.Log.Comment, "bodies of 1 responses are omitted", "should note omitted bodies in archive")
+}
+
+func TestSeed(t *testing.T) {
+	urlFake, _ := url.Parse(fakeLink)
+	crawler := NewCrawler(context.Background(), urlFake)
+	crawler.SetNumberOfThreads(2)
+	crawler.client = &httpClientRedirectStub{}
+
+	crawler.ExploreLink(NewLink(fakeLink))
+	crawler.Seed([]*Link{NewLink(fakeLink + "account"), NewLink("https://other.site/")})
+	crawler.Wait()
+
+	_, visited := crawler.Result.Load(fakeLink + "account")
+	require.True(t, visited, "should visit seed links")
+	_, visited = crawler.Result.Load("https://other.site/")
+	require.False(t, visited, "should skip out-of-scope seed links")
+}
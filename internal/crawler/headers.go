@@ -0,0 +1,155 @@
+package crawler
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+//FindingSecurityHeaders type of [crawler.SecurityHeadersCheck] findings
+const FindingSecurityHeaders = "security-headers"
+
+//MinHSTSMaxAge min Strict-Transport-Security max-age (half a year) not considered weak
+const MinHSTSMaxAge = 15768000
+
+var (
+	rgxHSTSMaxAge  = regexp.MustCompile(`(?i)max-age\s*=\s*"?(\d+)`)
+	rgxCSPWildcard = regexp.MustCompile(`(default|script)-src[^;]*\s\*(\s|;|$)`)
+)
+
+//SecurityHeadersCheck passive check for missing or weak security headers & permissive CORS, reported once per host
+type SecurityHeadersCheck struct{}
+
+//Type returns type of the check findings
+func (SecurityHeadersCheck) Type() string {
+	return FindingSecurityHeaders
+}
+
+//Check returns findings for missing or weak security headers of a given response
+func (check SecurityHeadersCheck) Check(resp *Response) []*Finding {
+	if resp.Header == nil || resp.VisitedLink == nil {
+		return nil
+	}
+
+	var result []*Finding
+	addFinding := func(header, payload, severity string) {
+		result = append(result, &Finding{
+			Type:     check.Type(),
+			URL:      resp.VisitedLink.URL,
+			Scope:    hostOf(resp.VisitedLink.URL),
+			Param:    header,
+			Payload:  payload,
+			Evidence: header + ": " + resp.Header.Get(header),
+			Severity: severity,
+		})
+	}
+
+	for _, hc := range headerChecks {
+		if payload, severity := hc.check(resp); payload != "" {
+			addFinding(hc.header, payload, severity)
+		}
+	}
+
+	return result
+}
+
+//headerChecks header names with functions returning issue description & severity, empty description if no issue
+var headerChecks = []struct {
+	header string
+	check  func(resp *Response) (string, string)
+}{
+	{"Content-Security-Policy", checkCSP},
+	{"Strict-Transport-Security", checkHSTS},
+	{"X-Frame-Options", checkFrameOptions},
+	{"X-Content-Type-Options", checkContentTypeOptions},
+	{"Referrer-Policy", checkReferrerPolicy},
+	{"Access-Control-Allow-Origin", checkCORS},
+}
+
+func checkCSP(resp *Response) (string, string) {
+	csp := strings.ToLower(resp.Header.Get("Content-Security-Policy"))
+	switch {
+	case csp == "":
+		return "missing Content-Security-Policy header", SeverityMedium
+	case strings.Contains(csp, "'unsafe-inline'") || strings.Contains(csp, "'unsafe-eval'"):
+		return "weak Content-Security-Policy: unsafe-inline or unsafe-eval allowed", SeverityLow
+	case !strings.Contains(csp, "default-src") && !strings.Contains(csp, "script-src"):
+		return "weak Content-Security-Policy: no default-src or script-src directive", SeverityLow
+	case rgxCSPWildcard.MatchString(csp):
+		return "weak Content-Security-Policy: wildcard source allowed", SeverityLow
+	}
+
+	return "", ""
+}
+
+func checkHSTS(resp *Response) (string, string) {
+	if !strings.HasPrefix(resp.VisitedLink.URL, "https://") {
+		return "", ""
+	}
+
+	hsts := resp.Header.Get("Strict-Transport-Security")
+	if hsts == "" {
+		return "missing Strict-Transport-Security header", SeverityMedium
+	}
+	match := rgxHSTSMaxAge.FindStringSubmatch(hsts)
+	if match == nil {
+		return "weak Strict-Transport-Security: no max-age", SeverityLow
+	}
+	if maxAge, _ := strconv.Atoi(match[1]); maxAge < MinHSTSMaxAge {
+		return "weak Strict-Transport-Security: max-age less than half a year", SeverityLow
+	}
+
+	return "", ""
+}
+
+func checkFrameOptions(resp *Response) (string, string) {
+	if strings.Contains(strings.ToLower(resp.Header.Get("Content-Security-Policy")), "frame-ancestors") {
+		return "", ""
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(resp.Header.Get("X-Frame-Options"))) {
+	case "DENY", "SAMEORIGIN":
+		return "", ""
+	case "":
+		return "missing X-Frame-Options header", SeverityMedium
+	default:
+		return "weak X-Frame-Options: neither DENY nor SAMEORIGIN", SeverityLow
+	}
+}
+
+func checkContentTypeOptions(resp *Response) (string, string) {
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("X-Content-Type-Options"))) {
+	case "nosniff":
+		return "", ""
+	case "":
+		return "missing X-Content-Type-Options header", SeverityLow
+	default:
+		return "weak X-Content-Type-Options: not nosniff", SeverityLow
+	}
+}
+
+func checkReferrerPolicy(resp *Response) (string, string) {
+	switch strings.ToLower(strings.TrimSpace(resp.Header.Get("Referrer-Policy"))) {
+	case "":
+		return "missing Referrer-Policy header", SeverityLow
+	case "unsafe-url", "no-referrer-when-downgrade":
+		return "weak Referrer-Policy: full url is leaked to other origins", SeverityLow
+	}
+
+	return "", ""
+}
+
+func checkCORS(resp *Response) (string, string) {
+	origin := strings.TrimSpace(resp.Header.Get("Access-Control-Allow-Origin"))
+	withCredentials := strings.EqualFold(resp.Header.Get("Access-Control-Allow-Credentials"), "true")
+	switch {
+	case origin == "*" && withCredentials:
+		return "permissive CORS: any origin allowed with credentials", SeverityHigh
+	case origin == "null":
+		return "permissive CORS: null origin allowed", SeverityMedium
+	case origin == "*":
+		return "permissive CORS: any origin allowed", SeverityLow
+	}
+
+	return "", ""
+}
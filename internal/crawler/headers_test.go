@@ -0,0 +1,78 @@
+package crawler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityHeadersCheck(t *testing.T) {
+	secureHeaders := http.Header{
+		"Content-Security-Policy":   []string{"default-src 'self'"},
+		"Strict-Transport-Security": []string{"max-age=31536000; includeSubDomains"},
+		"X-Frame-Options":           []string{"DENY"},
+		"X-Content-Type-Options":    []string{"nosniff"},
+		"Referrer-Policy":           []string{"strict-origin-when-cross-origin"},
+	}
+
+	weakHeaders := http.Header{
+		"Content-Security-Policy":          []string{"default-src 'self' 'unsafe-inline'"},
+		"Strict-Transport-Security":        []string{"max-age=60"},
+		"X-Frame-Options":                  []string{"ALLOW-FROM https://other.site/"},
+		"Referrer-Policy":                  []string{"unsafe-url"},
+		"Access-Control-Allow-Origin":      []string{"*"},
+		"Access-Control-Allow-Credentials": []string{"true"},
+	}
+
+	tabTests := []struct {
+		name     string
+		response *Response
+		expected []string
+	}{
+		{
+			name:     "no headers captured",
+			response: &Response{VisitedLink: NewLink(fakeLink)},
+			expected: nil,
+		},
+		{
+			name:     "secure headers",
+			response: &Response{VisitedLink: NewLink(fakeLink), Header: secureHeaders},
+			expected: nil,
+		},
+		{
+			name:     "missing headers on http",
+			response: &Response{VisitedLink: NewLink("http://this.is.link/"), Header: http.Header{}},
+			expected: []string{
+				"missing Content-Security-Policy header",
+				"missing X-Frame-Options header",
+				"missing X-Content-Type-Options header",
+				"missing Referrer-Policy header",
+			},
+		},
+		{
+			name:     "weak headers",
+			response: &Response{VisitedLink: NewLink(fakeLink), Header: weakHeaders},
+			expected: []string{
+				"weak Content-Security-Policy: unsafe-inline or unsafe-eval allowed",
+				"weak Strict-Transport-Security: max-age less than half a year",
+				"weak X-Frame-Options: neither DENY nor SAMEORIGIN",
+				"missing X-Content-Type-Options header",
+				"weak Referrer-Policy: full url is leaked to other origins",
+				"permissive CORS: any origin allowed with credentials",
+			},
+		},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, finding := range (SecurityHeadersCheck{}).Check(test.response) {
+				require.Equal(t, FindingSecurityHeaders, finding.Type, "should have check type")
+				require.Equal(t, "this.is.link", finding.Scope, "should be scoped by host")
+				got = append(got, finding.Payload)
+			}
+			require.Equal(t, test.expected, got, "should be equal")
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+)
+
+//Change of a page compared with the previous crawl, see [crawler.Crawler.Previous]
+type Change string
+
+//Changes of pages in incremental crawl
+const (
+	ChangeNew       = Change("new")       //page was not visited by the previous crawl
+	ChangeModified  = Change("modified")  //status or body of the page differs from the previous crawl
+	ChangeUnchanged = Change("unchanged") //server responded 304 Not Modified or with the same status & body
+)
+
+//PreviousPage page of a previous crawl of the same target used for incremental crawling
+type PreviousPage struct {
+	StatusCode   int       //http status code
+	ContentType  string    //media type of the body
+	ETag         string    //ETag header sent as If-None-Match, empty if page was redirected
+	LastModified string    //Last-Modified header sent as If-Modified-Since, empty if page was redirected
+	Digest       string    //digest of the body, see [crawler.Response.Digest]
+	Features     []Feature //features of the page
+	Forms        []*Form   //forms of the page
+	Fingerprint  uint64    //simhash of the page DOM shape
+	Soft404      bool      //page looked like the host response for nonexistent paths
+	Links        []*Link   //links found on the page, reused if the page is unchanged
+}
+
+//PreviousCrawl pages of a previous crawl of the same target by url
+type PreviousCrawl map[string]*PreviousPage
+
+//conditionalHeader returns If-None-Match & If-Modified-Since headers for a page of the previous crawl, nil if there are no validators
+func (cr *Crawler) conditionalHeader(link string) http.Header {
+	prev, ok := cr.Previous[link]
+	if !ok || (prev.ETag == "" && prev.LastModified == "") {
+		return nil
+	}
+
+	header := http.Header{}
+	if prev.ETag != "" {
+		header.Set("If-None-Match", prev.ETag)
+	}
+	if prev.LastModified != "" {
+		header.Set("If-Modified-Since", prev.LastModified)
+	}
+
+	return header
+}
+
+//fillDigest calculates resp.Digest from resp.RawBody
+func (resp *Response) fillDigest() {
+	if resp.RawBody == nil {
+		return
+	}
+
+	sum := sha256.Sum256(resp.RawBody)
+	resp.Digest = hex.EncodeToString(sum[:])
+}
+
+//compareWithPrevious sets resp.Change if the previous crawl is given,
+//parameters of 304 Not Modified response are restored from the previous crawl
+func (cr *Crawler) compareWithPrevious(resp *Response) {
+	if cr.Previous == nil {
+		return
+	}
+
+	prev, ok := cr.Previous[resp.VisitedLink.URL]
+	switch {
+	case !ok:
+		resp.Change = ChangeNew
+	case resp.StatusCode == http.StatusNotModified:
+		resp.Change = ChangeUnchanged
+		resp.restoreFrom(prev)
+	case resp.StatusCode == prev.StatusCode && resp.Digest != "" && resp.Digest == prev.Digest:
+		resp.Change = ChangeUnchanged
+		resp.Soft404 = prev.Soft404
+	default:
+		resp.Change = ChangeModified
+	}
+}
+
+func (resp *Response) restoreFrom(prev *PreviousPage) {
+	resp.StatusCode = prev.StatusCode
+	resp.ContentType = prev.ContentType
+	resp.Digest = prev.Digest
+	resp.Fingerprint = prev.Fingerprint
+	resp.Soft404 = prev.Soft404
+	resp.SetFeature(prev.Features...)
+	resp.Forms = prev.Forms
+
+	if resp.Header == nil {
+		resp.Header = http.Header{}
+	}
+	if resp.Header.Get("ETag") == "" && prev.ETag != "" {
+		resp.Header.Set("ETag", prev.ETag)
+	}
+	if resp.Header.Get("Last-Modified") == "" && prev.LastModified != "" {
+		resp.Header.Set("Last-Modified", prev.LastModified)
+	}
+}
+
+//previousLinks returns links found on unchanged page by the previous crawl, false if page should be parsed
+func (cr *Crawler) previousLinks(resp *Response) ([]*Link, bool) {
+	if resp.Change != ChangeUnchanged {
+		return nil, false
+	}
+	prev, ok := cr.Previous[resp.VisitedLink.URL]
+	if !ok {
+		return nil, false
+	}
+
+	result := make([]*Link, 0, len(prev.Links))
+	for _, l := range prev.Links {
+		link := NewLink(l.URL, resp.VisitedLink.Jumps+1)
+		link.Extractor = l.Extractor
+		result = append(result, link)
+	}
+
+	return result, true
+}
@@ -0,0 +1,139 @@
+package crawler
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type httpClientETagStub struct {
+	mu       sync.Mutex
+	pages    map[string]string
+	etags    map[string]string
+	requests map[string]int
+}
+
+func (hCl *httpClientETagStub) Do(req *http.Request) (*http.Response, error) {
+	link := req.URL.String()
+	hCl.mu.Lock()
+	hCl.requests[link]++
+	hCl.mu.Unlock()
+
+	header := http.Header{"Etag": {hCl.etags[link]}}
+	if etag := req.Header.Get("If-None-Match"); etag != "" && etag == hCl.etags[link] {
+		return &http.Response{StatusCode: http.StatusNotModified, Header: header, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	}
+	body, ok := hCl.pages[link]
+	if !ok {
+		return &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	}
+
+	return &http.Response{StatusCode: http.StatusOK, Header: header, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+}
+
+//urlCheck passive check reporting every checked url
+type urlCheck struct{}
+
+func (urlCheck) Type() string { return "url" }
+
+func (urlCheck) Check(resp *Response) []*Finding {
+	return []*Finding{{Type: "url", URL: resp.VisitedLink.URL, Scope: resp.VisitedLink.URL}}
+}
+
+func TestIncrementalCrawl(t *testing.T) {
+	urlFake, _ := url.Parse(fakeLink)
+	client := &httpClientETagStub{
+		pages: map[string]string{
+			fakeLink:            "<a href='/cached'>cached</a>",
+			fakeLink + "cached": "changed body, links are not parsed <a href='/ignored'>x</a>",
+			fakeLink + "same":   "<form></form>",
+			fakeLink + "edited": "new text",
+			fakeLink + "new":    "new page",
+		},
+		etags: map[string]string{
+			fakeLink:            `"root-v2"`,
+			fakeLink + "cached": `"cached-v1"`,
+		},
+		requests: map[string]int{},
+	}
+	crawler := NewCrawler(context.Background(), urlFake)
+	crawler.SetNumberOfThreads(2)
+	crawler.MaxJumps = 3
+	crawler.client = client
+	crawler.Checks = []PassiveCheck{urlCheck{}}
+
+	sameDigest := NewResponse(NewLink(fakeLink+"same"), http.StatusOK)
+	sameDigest.RawBody = []byte("<form></form>")
+	sameDigest.fillDigest()
+	crawler.Previous = PreviousCrawl{
+		fakeLink: {StatusCode: http.StatusOK, ETag: `"root-v1"`},
+		fakeLink + "cached": {
+			StatusCode:  http.StatusOK,
+			ContentType: "text/html",
+			ETag:        `"cached-v1"`,
+			Digest:      "abc",
+			Features:    []Feature{FeatureForm},
+			Links: []*Link{
+				{URL: fakeLink + "same", Extractor: "a[href]"},
+				{URL: fakeLink + "edited", Extractor: "a[href]"},
+				{URL: fakeLink + "new", Extractor: "script[src]"},
+			},
+		},
+		fakeLink + "same":   {StatusCode: http.StatusOK, Digest: sameDigest.Digest},
+		fakeLink + "edited": {StatusCode: http.StatusOK, Digest: "old"},
+	}
+
+	crawler.ExploreLink(NewLink(fakeLink))
+	time.Sleep(10 * time.Millisecond)
+	crawler.Wait()
+
+	changes := map[string]Change{}
+	crawler.Result.Range(func(key, value any) bool {
+		changes[key.(string)] = value.(*Response).Change
+
+		return true
+	})
+	require.Equal(t, map[string]Change{
+		fakeLink:            ChangeModified,
+		fakeLink + "cached": ChangeUnchanged,
+		fakeLink + "same":   ChangeUnchanged,
+		fakeLink + "edited": ChangeModified,
+		fakeLink + "new":    ChangeNew,
+	}, changes, "should compare pages with the previous crawl & reuse links of not modified pages")
+
+	value, _ := crawler.Result.Load(fakeLink + "cached")
+	cached := value.(*Response)
+	require.Equal(t, http.StatusOK, cached.StatusCode, "should restore status of not modified page")
+	require.Equal(t, "text/html", cached.ContentType, "should restore content type of not modified page")
+	require.True(t, cached.HasAnyFeature(FeatureForm), "should restore features of not modified page")
+	require.Zero(t, client.requests[fakeLink+"ignored"], "should not parse links of not modified page")
+
+	var checked []string
+	for _, finding := range crawler.Findings.ByType("url") {
+		checked = append(checked, finding.URL)
+	}
+	require.ElementsMatch(t, []string{fakeLink, fakeLink + "same", fakeLink + "edited", fakeLink + "new"}, checked,
+		"should not run passive checks on 304 responses")
+}
+
+func TestConditionalHeader(t *testing.T) {
+	crawler := NewCrawler(context.Background(), nil)
+	crawler.Previous = PreviousCrawl{
+		fakeLink:       {ETag: `"v1"`, LastModified: "Wed, 20 Jul 2022 10:00:00 GMT"},
+		fakeLink + "a": {StatusCode: http.StatusOK},
+	}
+
+	require.Equal(t, http.Header{
+		"If-None-Match":     {`"v1"`},
+		"If-Modified-Since": {"Wed, 20 Jul 2022 10:00:00 GMT"},
+	}, crawler.conditionalHeader(fakeLink), "should send validators of the previous crawl")
+	require.Nil(t, crawler.conditionalHeader(fakeLink+"a"), "no validators - no conditional request")
+	require.Nil(t, crawler.conditionalHeader(fakeLink+"b"), "new page - no conditional request")
+}
@@ -0,0 +1,87 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+//FindingMixedContent type of [crawler.MixedContentCheck] findings
+const FindingMixedContent = "mixed-content"
+
+//mixedContentElements selectors of active content with attribute holding its url, issue description & severity
+var mixedContentElements = []struct {
+	selector string
+	attr     string
+	payload  string
+	severity string
+}{
+	{"script[src]", "src", "script loaded over HTTP", SeverityHigh},
+	{"link[href]", "href", "stylesheet loaded over HTTP", SeverityMedium},
+	{"iframe[src], frame[src]", "src", "frame loaded over HTTP", SeverityMedium},
+}
+
+//MixedContentCheck passive check for scripts, stylesheets & frames loaded over HTTP by HTTPS pages
+type MixedContentCheck struct{}
+
+//Type returns type of the check findings
+func (MixedContentCheck) Type() string {
+	return FindingMixedContent
+}
+
+//Check returns findings for insecure resources loaded by a given response page
+func (check MixedContentCheck) Check(resp *Response) []*Finding {
+	if resp.BodyForQueries == nil || resp.VisitedLink == nil {
+		return nil
+	}
+	pageURL, err := url.Parse(resp.VisitedLink.URL)
+	if err != nil || pageURL.Scheme != "https" {
+		return nil
+	}
+
+	var result []*Finding
+	for _, element := range mixedContentElements {
+		resp.BodyForQueries.Find(element.selector).Each(func(i int, sel *goquery.Selection) {
+			if sel.Is("link") && !isStylesheetLink(sel) {
+				return
+			}
+			resourceURL, err := pageURL.Parse(strings.TrimSpace(sel.AttrOr(element.attr, "")))
+			if err != nil || resourceURL.Scheme != "http" {
+				return
+			}
+
+			result = append(result, &Finding{
+				Type:     check.Type(),
+				URL:      resp.VisitedLink.URL,
+				Scope:    resp.VisitedLink.URL,
+				Param:    resourceURL.String(),
+				Payload:  element.payload,
+				Evidence: outerHTML(sel),
+				Severity: element.severity,
+			})
+		})
+	}
+
+	return result
+}
+
+func isStylesheetLink(sel *goquery.Selection) bool {
+	for _, rel := range strings.Fields(strings.ToLower(sel.AttrOr("rel", ""))) {
+		if rel == "stylesheet" {
+			return true
+		}
+	}
+
+	return false
+}
+
+//outerHTML returns html of the selected element, used as finding evidence
+func outerHTML(sel *goquery.Selection) string {
+	html, err := goquery.OuterHtml(sel)
+	if err != nil {
+		return ""
+	}
+
+	return snippet([]byte(html), 0, 0)
+}
@@ -0,0 +1,49 @@
+package crawler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMixedContentCheck(t *testing.T) {
+	page := `<html><head>
+<script src="http://cdn.other.site/lib.js"></script>
+<script src="/app.js"></script>
+<link rel="stylesheet" href="http://cdn.other.site/style.css">
+<link rel="canonical" href="http://this.is.link/">
+</head><body><iframe src="http://ads.other.site/banner"></iframe><img src="http://cdn.other.site/logo.png"></body></html>`
+
+	tabTests := []struct {
+		name     string
+		link     string
+		expected []string
+	}{
+		{
+			name:     "https page",
+			link:     fakeLink,
+			expected: []string{"http://cdn.other.site/lib.js", "http://cdn.other.site/style.css", "http://ads.other.site/banner"},
+		},
+		{
+			name:     "http page",
+			link:     "http://this.is.link/",
+			expected: nil,
+		},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.name, func(t *testing.T) {
+			queryDoc, _ := goquery.NewDocumentFromReader(strings.NewReader(page))
+			response := &Response{VisitedLink: NewLink(test.link), BodyForQueries: queryDoc}
+
+			var got []string
+			for _, finding := range (MixedContentCheck{}).Check(response) {
+				require.NotEmpty(t, finding.Evidence, "should have element as evidence")
+				got = append(got, finding.Param)
+			}
+			require.Equal(t, test.expected, got, "should be equal")
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//Types of values in url segments & query parameters
+const (
+	ValueTypeInt   = "int"
+	ValueTypeFloat = "float"
+	ValueTypeBool  = "bool"
+	ValueTypeUUID  = "uuid"
+	ValueTypeHex   = "hex"
+	ValueTypeURL   = "url"
+	ValueTypeStr   = "str"
+)
+
+var (
+	rgxIntValue   = regexp.MustCompile(`^-?\d+$`)
+	rgxFloatValue = regexp.MustCompile(`^-?\d+\.\d+$`)
+	rgxUUIDValue  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	rgxHexValue   = regexp.MustCompile(`^[0-9a-fA-F]{16,}$`)
+	rgxURLValue   = regexp.MustCompile(`^(?i)(https?:)?//`)
+)
+
+//URLPattern returns link path with variable segments (numbers, uuids, hashes) replaced by placeholders,
+//followed by sorted query keys without values, e.g. "/users/{int}/orders?page&sort"
+func URLPattern(link string) string {
+	parsedURL, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+
+	segments := strings.Split(parsedURL.Path, "/")
+	for i, segment := range segments {
+		segments[i] = segmentPlaceholder(segment)
+	}
+	pattern := strings.Join(segments, "/")
+
+	query := parsedURL.Query()
+	if len(query) == 0 {
+		return pattern
+	}
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return pattern + "?" + strings.Join(keys, "&")
+}
+
+func segmentPlaceholder(segment string) string {
+	switch valueType := ValueType(segment); valueType {
+	case ValueTypeInt, ValueTypeUUID, ValueTypeHex:
+		return "{" + valueType + "}"
+	default:
+		return segment
+	}
+}
+
+//ValueType returns the type of given url segment or query parameter value, one of ValueType... constants
+func ValueType(value string) string {
+	switch {
+	case rgxIntValue.MatchString(value):
+		return ValueTypeInt
+	case rgxFloatValue.MatchString(value):
+		return ValueTypeFloat
+	case value == "true" || value == "false":
+		return ValueTypeBool
+	case rgxUUIDValue.MatchString(value):
+		return ValueTypeUUID
+	case rgxHexValue.MatchString(value):
+		return ValueTypeHex
+	case rgxURLValue.MatchString(value):
+		return ValueTypeURL
+	default:
+		return ValueTypeStr
+	}
+}
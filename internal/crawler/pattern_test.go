@@ -0,0 +1,37 @@
+package crawler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLPattern(t *testing.T) {
+	tabTests := []struct {
+		name     string
+		link     string
+		expected string
+	}{
+		{
+			name:     "static path",
+			link:     fakeLink + "about/",
+			expected: "/about/",
+		},
+		{
+			name:     "variable segments",
+			link:     fakeLink + "users/42/avatars/0123456789abcdef0123/c56a4180-65aa-42ec-a945-5fd21dec0538",
+			expected: "/users/{int}/avatars/{hex}/{uuid}",
+		},
+		{
+			name:     "query keys are sorted without values",
+			link:     fakeLink + "calendar?year=2022&month=7&day=1",
+			expected: "/calendar?day&month&year",
+		},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, URLPattern(test.link), "should be equal")
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//FindingSensitiveFile type of findings of [crawler.Crawler.Probe] phase
+const FindingSensitiveFile = "sensitive-file"
+
+//ProbeFilePlaceholder is replaced in probe paths with names of files discovered in the directory
+const ProbeFilePlaceholder = "{file}"
+
+//go:embed rules/probe.txt
+var defaultProbeWordlist []byte
+
+//ProbeEntry path to request under discovered directories
+type ProbeEntry struct {
+	Path      string         //path relative to the directory, may contain ProbeFilePlaceholder
+	Signature *regexp.Regexp //if set, body should match it to confirm the hit
+}
+
+type probeCandidate struct {
+	link  string
+	dir   string
+	entry *ProbeEntry
+}
+
+//LoadProbeWordlist reads probe entries from a given file, from embedded default wordlist if path is empty
+//each line is a path optionally followed by a tab and a regexp confirming the hit, lines starting with # are skipped
+func LoadProbeWordlist(wordlistPath string) ([]*ProbeEntry, error) {
+	data := defaultProbeWordlist
+	if wordlistPath != "" {
+		var err error
+		if data, err = os.ReadFile(wordlistPath); err != nil {
+			return nil, fmt.Errorf("error reading probe wordlist: %w", err)
+		}
+	}
+
+	var result []*ProbeEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "\t", 2)
+		entry := &ProbeEntry{Path: strings.TrimPrefix(parts[0], "/")}
+		if len(parts) == 2 && parts[1] != "" {
+			rgx, err := regexp.Compile(parts[1])
+			if err != nil {
+				return nil, fmt.Errorf("wrong signature of probe path %s: %w", entry.Path, err)
+			}
+			entry.Signature = rgx
+		}
+		result = append(result, entry)
+	}
+
+	return result, scanner.Err()
+}
+
+//Probe requests given paths under every directory found in cr.Result, confirmed hits are stored into cr.Result
+//and reported as FindingSensitiveFile findings, responses looking like soft-404 pages are ignored
+func (cr *Crawler) Probe(entries []*ProbeEntry) {
+	candidates := cr.probeCandidates(entries)
+	if cr.Budget.MaxProbes > 0 && len(candidates) > cr.Budget.MaxProbes {
+		candidates = candidates[:cr.Budget.MaxProbes]
+	}
+	log.Printf("Probing %d paths on %s\n", len(candidates), cr.URL)
+
+	cr.runWorkers(len(candidates), func(i int) {
+		cr.probe(candidates[i])
+	})
+}
+
+func (cr *Crawler) probe(candidate *probeCandidate) {
+	if cr.isDone() {
+		return
+	}
+
+	resp, err := cr.makeGetRequest(NewLink(candidate.link))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return
+	}
+
+	baseline := cr.soft404Baseline(candidate.dir, randomPathName())
+	var evidence []int
+	if signature := candidate.entry.Signature; signature != nil {
+		if evidence = signature.FindIndex(resp.RawBody); evidence == nil || baseline.bodiesMatch(signature.Match) {
+			return
+		}
+	} else if baseline.matches(resp) {
+		return
+	}
+
+	resp.FillResponseParameters()
+	if evidence == nil {
+		evidence = []int{0, 0}
+	}
+	if cr.Findings != nil {
+		cr.Findings.Add(&Finding{
+			Type:     FindingSensitiveFile,
+			URL:      candidate.link,
+			Scope:    candidate.link,
+			Param:    candidate.entry.Path,
+			Payload:  "exposed sensitive file or path",
+			Evidence: snippet(resp.RawBody, evidence[0], evidence[1]),
+			Severity: SeverityMedium,
+		})
+	}
+	resp.ClearResponseBody()
+	cr.Result.LoadOrStore(candidate.link, resp)
+}
+
+//probeCandidates returns not yet visited urls of entries under all directories found in cr.Result
+func (cr *Crawler) probeCandidates(entries []*ProbeEntry) []*probeCandidate {
+	filesByDir := map[string][]string{}
+	var dirs []string
+	cr.Result.Range(func(key, value any) bool {
+		link, ok := key.(string)
+		if !ok {
+			return true
+		}
+		for dir, file := range directoriesOf(link) {
+			if _, ok := filesByDir[dir]; !ok {
+				dirs = append(dirs, dir)
+				filesByDir[dir] = nil
+			}
+			if file != "" {
+				filesByDir[dir] = append(filesByDir[dir], file)
+			}
+		}
+
+		return true
+	})
+
+	sort.Slice(dirs, func(i, j int) bool {
+		depthI, depthJ := strings.Count(dirs[i], "/"), strings.Count(dirs[j], "/")
+		if depthI != depthJ {
+			return depthI < depthJ
+		}
+
+		return dirs[i] < dirs[j]
+	})
+
+	var result []*probeCandidate
+	seen := map[string]bool{}
+	for _, dir := range dirs {
+		for _, entry := range entries {
+			for _, probePath := range expandProbePath(entry.Path, filesByDir[dir]) {
+				link := dir + probePath
+				if _, visited := cr.Result.Load(link); visited || seen[link] || !cr.canVisitLink(link) {
+					continue
+				}
+				seen[link] = true
+				result = append(result, &probeCandidate{link: link, dir: dir, entry: entry})
+			}
+		}
+	}
+
+	return result
+}
+
+//directoriesOf returns urls of the link directory & all its parents (ending with "/"),
+//mapped to the file name of the link in its own directory
+func directoriesOf(link string) map[string]string {
+	parsedURL, err := url.Parse(link)
+	if err != nil || parsedURL.Host == "" {
+		return nil
+	}
+
+	base := parsedURL.Scheme + "://" + parsedURL.Host
+	dir, file := path.Split(parsedURL.Path)
+	if dir == "" {
+		dir = "/"
+	}
+	if !strings.Contains(file, ".") {
+		file = ""
+	}
+
+	result := map[string]string{base + dir: file}
+	for dir != "/" {
+		dir, _ = path.Split(strings.TrimSuffix(dir, "/"))
+		result[base+dir] = ""
+	}
+
+	return result
+}
+
+func expandProbePath(probePath string, files []string) []string {
+	if !strings.Contains(probePath, ProbeFilePlaceholder) {
+		return []string{probePath}
+	}
+
+	result := make([]string, 0, len(files))
+	for _, file := range files {
+		result = append(result, strings.ReplaceAll(probePath, ProbeFilePlaceholder, file))
+	}
+
+	return result
+}
@@ -0,0 +1,108 @@
+package crawler
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const softNotFoundBody = "<html><body><h1>Sorry</h1><p>The page you requested was not found on this server</p></body></html>"
+
+//httpClientSiteStub serves given pages, answers 200 with the same "not found" page for unknown paths
+type httpClientSiteStub struct {
+	pages map[string]string
+}
+
+func (hCl *httpClientSiteStub) Do(req *http.Request) (*http.Response, error) {
+	body, ok := hCl.pages[req.URL.String()]
+	if !ok {
+		body = softNotFoundBody + "<!-- " + req.URL.Path + " -->"
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}, nil
+}
+
+func TestLoadProbeWordlist(t *testing.T) {
+	entries, err := LoadProbeWordlist("")
+	require.NoError(t, err, "default wordlist should be valid")
+	require.NotEmpty(t, entries, "default wordlist should not be empty")
+
+	wordlist := filepath.Join(t.TempDir(), "wordlist.txt")
+	require.NoError(t, os.WriteFile(wordlist, []byte("# comment\n/.env\t^[A-Z]+=\n\nadmin/\n"), 0o600))
+	entries, err = LoadProbeWordlist(wordlist)
+	require.NoError(t, err, "no error expected")
+	require.Len(t, entries, 2, "should skip comments & empty lines")
+	require.Equal(t, ".env", entries[0].Path, "should trim leading slash")
+	require.NotNil(t, entries[0].Signature, "should compile signature")
+	require.Nil(t, entries[1].Signature, "signature is optional")
+}
+
+func TestProbe(t *testing.T) {
+	urlFake, _ := url.Parse(fakeLink)
+	crawler := NewCrawler(context.Background(), urlFake)
+	crawler.SetNumberOfThreads(2)
+	crawler.client = &httpClientSiteStub{pages: map[string]string{
+		fakeLink + "app/.git/HEAD":     "ref: refs/heads/main",
+		fakeLink + "app/index.php.bak": "<?php $password = 'secret'; ?>",
+		fakeLink + "admin/":            "<html><body><form><input name='login'></form></body></html>",
+		fakeLink + "app/backup.zip":    "PK\x03\x04\x14\x00\x00\x00",
+		fakeLink + "app/.env":          strings.Repeat("# local settings\n", 10) + "DB_PASSWORD=secret",
+	}}
+	crawler.Result.Store(fakeLink+"app/index.php", &Response{})
+	crawler.Budget.MaxPages = 1
+	crawler.takePage()
+	require.False(t, crawler.takePage(), "crawl budget should be exhausted")
+
+	crawler.Probe([]*ProbeEntry{
+		{Path: ".git/HEAD", Signature: regexpMust(`^ref: refs/`)},
+		{Path: ".env", Signature: regexpMust(`(?m)^[A-Z_]+=`)},
+		{Path: "admin/"},
+		{Path: "{file}.bak"},
+		{Path: "backup.zip", Signature: regexpMust(`^PK`)},
+		{Path: "missing/"},
+	})
+
+	var hits []string
+	for _, finding := range crawler.Findings.ByType(FindingSensitiveFile) {
+		hits = append(hits, finding.URL)
+		if finding.URL == fakeLink+"app/.env" {
+			require.Contains(t, finding.Evidence, "DB_PASSWORD=", "evidence should show the signature match")
+		}
+	}
+	require.ElementsMatch(t, []string{
+		fakeLink + "app/.git/HEAD",
+		fakeLink + "app/index.php.bak",
+		fakeLink + "admin/",
+		fakeLink + "app/backup.zip",
+		fakeLink + "app/.env",
+	}, hits, "should report confirmed hits only")
+
+	_, stored := crawler.Result.Load(fakeLink + "admin/")
+	require.True(t, stored, "should store hits into result")
+	_, stored = crawler.Result.Load(fakeLink + "missing/")
+	require.False(t, stored, "should not store soft-404 pages")
+}
+
+func TestDirectoriesOf(t *testing.T) {
+	require.Equal(t, map[string]string{
+		"https://this.is.link/a/b/": "index.php",
+		"https://this.is.link/a/":   "",
+		"https://this.is.link/":     "",
+	}, directoriesOf(fakeLink+"a/b/index.php?x=1"), "should return all parent directories")
+}
+
+func regexpMust(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(pattern)
+}
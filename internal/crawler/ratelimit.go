@@ -0,0 +1,45 @@
+package crawler
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+//rateLimiter spreads requests evenly, one per interval
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+//wait blocks until the next request is allowed, returns false if ctx is done first
+func (rl *rateLimiter) wait(ctx context.Context) bool {
+	rl.mu.Lock()
+	now := time.Now()
+	if rl.next.Before(now) {
+		rl.next = now
+	}
+	delay := rl.next.Sub(now)
+	rl.next = rl.next.Add(rl.interval)
+	rl.mu.Unlock()
+
+	if delay <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	if ctx == nil {
+		<-timer.C
+
+		return true
+	}
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,23 @@
+package crawler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiterWait(t *testing.T) {
+	limiter := &rateLimiter{interval: 10 * time.Millisecond}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		require.True(t, limiter.wait(context.Background()), "should pass limiter")
+	}
+	require.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond, "should spread requests")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	limiter.next = time.Now().Add(time.Hour)
+	require.False(t, limiter.wait(ctx), "should exit on context done")
+}
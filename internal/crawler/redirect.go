@@ -0,0 +1,134 @@
+package crawler
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+//DefaultMaxRedirects default max number of redirects followed for a single request
+const DefaultMaxRedirects = 10
+
+//Redirect single hop of a redirect chain
+type Redirect struct {
+	URL        string //requested url
+	StatusCode int    //redirect http status code
+	Location   string //absolute url the request is redirected to
+}
+
+//RedirectParams names (lower case) of query parameters commonly holding a redirect target
+var RedirectParams = map[string]bool{
+	"next": true, "url": true, "uri": true, "redirect": true, "redirect_to": true, "redirect_url": true, "redirect_uri": true,
+	"redirecturl": true, "return": true, "returnto": true, "return_to": true, "returnurl": true, "return_url": true,
+	"goto": true, "dest": true, "destination": true, "continue": true, "forward": true, "target": true, "to": true,
+	"out": true, "callback": true, "checkout_url": true, "success_url": true, "login_url": true,
+}
+
+//hasRedirectParam returns true for open redirect candidates: links with redirect-like parameters holding urls
+//and redirect responses whose Location is taken from a query parameter
+func hasRedirectParam(resp *Response) bool {
+	if resp.VisitedLink == nil {
+		return false
+	}
+	linkURL, err := url.Parse(resp.VisitedLink.URL)
+	if err != nil {
+		return false
+	}
+
+	for name, values := range linkURL.Query() {
+		for _, value := range values {
+			if isRedirectTarget(name, value) {
+				return true
+			}
+		}
+	}
+
+	location := resp.Header.Get("Location")
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 && location != "" && reflectsQueryParam(resp.VisitedLink.URL, location) {
+		return true
+	}
+	for _, hop := range resp.Redirects {
+		if reflectsQueryParam(hop.URL, hop.Location) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//reflectsQueryParam returns true if redirect location of a given link is taken from one of its query parameters
+func reflectsQueryParam(link, location string) bool {
+	linkURL, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+
+	for _, values := range linkURL.Query() {
+		for _, value := range values {
+			if reflectsInLocation(value, location) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+//isRedirectTarget returns true if a given parameter value is an absolute url or a path under redirect-like parameter
+func isRedirectTarget(name, value string) bool {
+	if ValueType(value) == ValueTypeURL {
+		return true
+	}
+
+	return RedirectParams[strings.ToLower(name)] && strings.HasPrefix(value, "/")
+}
+
+//reflectsInLocation returns true if redirect location starts with a given parameter value or points to the host it holds
+func reflectsInLocation(value, location string) bool {
+	if len(value) < 2 {
+		return false
+	}
+	if strings.HasPrefix(location, value) {
+		return true
+	}
+	locationURL, err := url.Parse(location)
+	if err != nil {
+		return false
+	}
+
+	//locations of followed hops are absolute, so a relative value is compared with the path
+	return strings.HasPrefix(locationURL.RequestURI(), value) ||
+		(locationURL.Host != "" && strings.EqualFold(locationURL.Host, value))
+}
+
+//redirectLocation returns absolute url of a redirect response Location, empty string if it is not a redirect
+func redirectLocation(resp *http.Response, requestURL string) string {
+	location := resp.Header.Get("Location")
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 || location == "" {
+		return ""
+	}
+
+	baseURL, err := url.Parse(requestURL)
+	if err != nil {
+		return ""
+	}
+	locationURL, err := baseURL.Parse(location)
+	if err != nil {
+		return ""
+	}
+	locationURL.Fragment = ""
+
+	return locationURL.String()
+}
+
+//redirectLinks returns targets of the redirect chain to be visited as discovered links, so they are stored as own endpoints
+func (resp *Response) redirectLinks() []*Link {
+	result := make([]*Link, 0, len(resp.Redirects))
+	for _, hop := range resp.Redirects {
+		link := NewLink(hop.Location, resp.VisitedLink.Jumps)
+		link.Extractor = ExtractorRedirect
+		result = append(result, link)
+	}
+
+	return result
+}
@@ -0,0 +1,251 @@
+package crawler
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasRedirectParam(t *testing.T) {
+	tabTests := []struct {
+		name     string
+		response *Response
+		expected bool
+	}{
+		{
+			name:     "no redirect params",
+			response: &Response{VisitedLink: NewLink(fakeLink + "search?q=shoes&page=2"), StatusCode: http.StatusOK},
+			expected: false,
+		},
+		{
+			name:     "absolute url value",
+			response: &Response{VisitedLink: NewLink(fakeLink + "out?link=https%3A%2F%2Fother.site%2F"), StatusCode: http.StatusOK},
+			expected: true,
+		},
+		{
+			name:     "path under redirect-like param",
+			response: &Response{VisitedLink: NewLink(fakeLink + "login?returnTo=/account"), StatusCode: http.StatusOK},
+			expected: true,
+		},
+		{
+			name:     "path under ordinary param",
+			response: &Response{VisitedLink: NewLink(fakeLink + "files?dir=/docs"), StatusCode: http.StatusOK},
+			expected: false,
+		},
+		{
+			name: "location reflects param",
+			response: &Response{
+				VisitedLink: NewLink(fakeLink + "go?site=other.site"),
+				StatusCode:  http.StatusFound,
+				Header:      http.Header{"Location": []string{"https://other.site/"}},
+			},
+			expected: true,
+		},
+		{
+			name: "location does not reflect param",
+			response: &Response{
+				VisitedLink: NewLink(fakeLink + "cart?item=1"),
+				StatusCode:  http.StatusFound,
+				Header:      http.Header{"Location": []string{"/login"}},
+			},
+			expected: false,
+		},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, hasRedirectParam(test.response), "should be equal")
+		})
+	}
+}
+
+//httpClientRedirectStub answers with redirects to Location of the requested url, 200 for urls without redirects
+type httpClientRedirectStub struct {
+	redirects map[string]string
+	mu        sync.Mutex
+	requested map[string]int
+}
+
+func (hCl *httpClientRedirectStub) Do(req *http.Request) (*http.Response, error) {
+	hCl.mu.Lock()
+	if hCl.requested == nil {
+		hCl.requested = map[string]int{}
+	}
+	hCl.requested[req.URL.String()]++
+	hCl.mu.Unlock()
+
+	location, ok := hCl.redirects[req.URL.String()]
+	if !ok {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader("<html><body>page</body></html>")),
+		}, nil
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusFound,
+		Header:     http.Header{"Location": []string{location}},
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}, nil
+}
+
+func TestMakeGetRequestRedirects(t *testing.T) {
+	stub := &httpClientRedirectStub{redirects: map[string]string{
+		fakeLink + "old":    "/new",
+		fakeLink + "new":    fakeLink + "newest",
+		fakeLink + "away":   "https://other.site/",
+		fakeLink + "loop/a": "/loop/b",
+		fakeLink + "loop/b": "/loop/a",
+		fakeLink + "hop/1":  "/hop/2",
+		fakeLink + "hop/2":  "/hop/3",
+		fakeLink + "hop/3":  "/hop/4",
+	}}
+
+	tabTests := []struct {
+		name           string
+		link           string
+		expectedStatus int
+		expected       []*Redirect
+	}{
+		{
+			name:           "no redirects",
+			link:           fakeLink + "newest",
+			expectedStatus: http.StatusOK,
+			expected:       nil,
+		},
+		{
+			name:           "in-scope chain",
+			link:           fakeLink + "old",
+			expectedStatus: http.StatusOK,
+			expected: []*Redirect{
+				{URL: fakeLink + "old", StatusCode: http.StatusFound, Location: fakeLink + "new"},
+				{URL: fakeLink + "new", StatusCode: http.StatusFound, Location: fakeLink + "newest"},
+			},
+		},
+		{
+			name:           "out-of-scope hop is not followed",
+			link:           fakeLink + "away",
+			expectedStatus: http.StatusFound,
+			expected: []*Redirect{
+				{URL: fakeLink + "away", StatusCode: http.StatusFound, Location: "https://other.site/"},
+			},
+		},
+		{
+			name:           "redirects cap",
+			link:           fakeLink + "hop/1",
+			expectedStatus: http.StatusFound,
+			expected: []*Redirect{
+				{URL: fakeLink + "hop/1", StatusCode: http.StatusFound, Location: fakeLink + "hop/2"},
+				{URL: fakeLink + "hop/2", StatusCode: http.StatusFound, Location: fakeLink + "hop/3"},
+				{URL: fakeLink + "hop/3", StatusCode: http.StatusFound, Location: fakeLink + "hop/4"},
+			},
+		},
+		{
+			name:           "redirect loop is not followed",
+			link:           fakeLink + "loop/a",
+			expectedStatus: http.StatusFound,
+			expected: []*Redirect{
+				{URL: fakeLink + "loop/a", StatusCode: http.StatusFound, Location: fakeLink + "loop/b"},
+				{URL: fakeLink + "loop/b", StatusCode: http.StatusFound, Location: fakeLink + "loop/a"},
+			},
+		},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.name, func(t *testing.T) {
+			urlFake, _ := url.Parse(fakeLink)
+			crawler := NewCrawler(context.Background(), urlFake)
+			crawler.client = stub
+			crawler.MaxRedirects = 2
+
+			response, err := crawler.makeGetRequest(NewLink(test.link))
+			require.NoError(t, err, "no error expected")
+			require.Equal(t, test.expectedStatus, response.StatusCode, "should be equal")
+			require.Equal(t, test.expected, response.Redirects, "should record redirect chain")
+		})
+	}
+}
+
+func TestExploreLinkRedirectTargets(t *testing.T) {
+	urlFake, _ := url.Parse(fakeLink)
+	crawler := NewCrawler(context.Background(), urlFake)
+	crawler.SetNumberOfThreads(2)
+	crawler.MaxJumps = 1
+	stub := &httpClientRedirectStub{redirects: map[string]string{
+		fakeLink: "/home",
+	}}
+	crawler.client = stub
+	crawler.MaxRedirects = 0
+
+	crawler.ExploreLink(NewLink(fakeLink))
+	crawler.Wait()
+
+	_, visited := crawler.Result.Load(fakeLink + "home")
+	require.True(t, visited, "should visit redirect target which was not followed")
+
+	crawler = NewCrawler(context.Background(), urlFake)
+	crawler.MaxJumps = 1
+	crawler.client = stub
+	stub.requested = nil
+
+	crawler.ExploreLink(NewLink(fakeLink))
+	crawler.Wait()
+
+	target, visited := crawler.Result.Load(fakeLink + "home")
+	require.True(t, visited, "followed redirect target should be stored as its own endpoint")
+	require.Equal(t, fakeLink+"home", target.(*Response).VisitedLink.URL, "should be equal")
+	require.Empty(t, target.(*Response).Redirects, "target endpoint should be requested by its own url")
+	source, _ := crawler.Result.Load(fakeLink)
+	require.Len(t, source.(*Response).Redirects, 1, "source endpoint should keep the redirect chain")
+}
+
+func TestRedirectParamWithRealClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/go" {
+			http.Redirect(w, r, r.URL.Query().Get("u"), http.StatusFound)
+
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL + "/")
+	link := server.URL + "/go?u=/home"
+
+	tabTests := []struct {
+		name           string
+		maxRedirects   int
+		expectedStatus int
+	}{
+		{
+			name:           "redirect is not followed",
+			maxRedirects:   0,
+			expectedStatus: http.StatusFound,
+		},
+		{
+			name:           "redirect is followed",
+			maxRedirects:   DefaultMaxRedirects,
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.name, func(t *testing.T) {
+			crawler := NewCrawler(context.Background(), serverURL)
+			crawler.MaxRedirects = test.maxRedirects
+
+			response, err := crawler.makeGetRequest(NewLink(link))
+			require.NoError(t, err, "no error expected")
+			require.Equal(t, test.expectedStatus, response.StatusCode, "client should not follow redirects itself")
+			require.True(t, hasRedirectParam(response), "location taken from a query param is an open redirect candidate")
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package crawler
+
+import (
+	"bytes"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/html"
+)
+
+//Contexts where a parameter value is reflected in the response
+const (
+	ReflectionInHTML      = "html"      //text between tags
+	ReflectionInAttribute = "attribute" //value of a tag attribute
+	ReflectionInScript    = "script"    //inside <script> or <style> element
+	ReflectionInHeader    = "header"    //response header value
+)
+
+//FeatureReflectedParam url has query parameters reflected in the response, see [crawler.Crawler.DetectReflections]
+const FeatureReflectedParam = Feature("reflected-param")
+
+//Reflection query parameter reflected in the response with contexts of its reflection
+type Reflection struct {
+	Param    string   //query parameter name
+	Contexts []string //sorted ReflectionIn... constants
+}
+
+type canaryCandidate struct {
+	resp  *Response
+	param string
+}
+
+func init() {
+	if err := RegisterFeature(FeatureReflectedParam, hasReflections); err != nil {
+		panic(err)
+	}
+}
+
+//DetectReflections re-requests urls with query parameters found in cr.Result replacing each parameter value with
+//a unique canary, parameters reflected in the response are stored into resp.Reflections
+func (cr *Crawler) DetectReflections() {
+	var candidates []*canaryCandidate
+	cr.Result.Range(func(key, value any) bool {
+		resp, ok := value.(*Response)
+		if !ok || resp.VisitedLink == nil {
+			return true
+		}
+		linkURL, err := url.Parse(resp.VisitedLink.URL)
+		if err != nil {
+			return true
+		}
+		for param := range linkURL.Query() {
+			candidates = append(candidates, &canaryCandidate{resp: resp, param: param})
+		}
+
+		return true
+	})
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].resp.VisitedLink.URL != candidates[j].resp.VisitedLink.URL {
+			return candidates[i].resp.VisitedLink.URL < candidates[j].resp.VisitedLink.URL
+		}
+
+		return candidates[i].param < candidates[j].param
+	})
+	if cr.Budget.MaxCanaries > 0 && len(candidates) > cr.Budget.MaxCanaries {
+		candidates = candidates[:cr.Budget.MaxCanaries]
+	}
+
+	mu := new(sync.Mutex)
+	cr.runWorkers(len(candidates), func(i int) {
+		candidate := candidates[i]
+		contexts := cr.canaryContexts(candidate.resp.VisitedLink.URL, candidate.param)
+		if len(contexts) == 0 {
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		candidate.resp.Reflections = append(candidate.resp.Reflections, &Reflection{Param: candidate.param, Contexts: contexts})
+		sort.Slice(candidate.resp.Reflections, func(i, j int) bool {
+			return candidate.resp.Reflections[i].Param < candidate.resp.Reflections[j].Param
+		})
+		candidate.resp.SetFeature(FeatureReflectedParam)
+	})
+}
+
+//canaryContexts requests a given link with a canary value of the param, returns contexts the canary is reflected in
+func (cr *Crawler) canaryContexts(link, param string) []string {
+	linkURL, err := url.Parse(link)
+	if err != nil {
+		return nil
+	}
+	canary := newCanary()
+	query := linkURL.Query()
+	query.Set(param, canary)
+	linkURL.RawQuery = query.Encode()
+
+	resp, err := cr.makeGetRequest(NewLink(linkURL.String()))
+	if err != nil {
+		return nil
+	}
+
+	contexts := map[string]bool{}
+	for _, values := range resp.Header {
+		for _, value := range values {
+			if strings.Contains(value, canary) {
+				contexts[ReflectionInHeader] = true
+			}
+		}
+	}
+	if bytes.Contains(resp.RawBody, []byte(canary)) {
+		for _, context := range bodyReflectionContexts(resp.RawBody, canary) {
+			contexts[context] = true
+		}
+	}
+
+	result := make([]string, 0, len(contexts))
+	for context := range contexts {
+		result = append(result, context)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+//bodyReflectionContexts returns html contexts a given canary is found in
+func bodyReflectionContexts(body []byte, canary string) []string {
+	var result []string
+	inScript := false
+	tokenizer := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return result
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			for _, attr := range token.Attr {
+				if strings.Contains(attr.Val, canary) {
+					result = append(result, ReflectionInAttribute)
+				}
+			}
+			inScript = token.Data == "script" || token.Data == "style"
+		case html.EndTagToken:
+			inScript = false
+		case html.TextToken:
+			if !strings.Contains(string(tokenizer.Text()), canary) {
+				continue
+			}
+			if inScript {
+				result = append(result, ReflectionInScript)
+			} else {
+				result = append(result, ReflectionInHTML)
+			}
+		}
+	}
+}
+
+func newCanary() string {
+	return "prbl" + strings.ReplaceAll(uuid.NewString(), "-", "")[:12]
+}
+
+func hasReflections(resp *Response) bool {
+	return len(resp.Reflections) > 0
+}
@@ -0,0 +1,80 @@
+package crawler
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+//httpClientEchoStub reflects query parameters: q in text, id in attribute, cb in script, lang in header, sort nowhere
+type httpClientEchoStub struct{}
+
+func (hCl *httpClientEchoStub) Do(req *http.Request) (*http.Response, error) {
+	query := req.URL.Query()
+	body := "<html><body><p>Results for " + query.Get("q") + "</p>" +
+		"<a href='/item?id=" + query.Get("id") + "'>item</a>" +
+		"<script>var callback = '" + query.Get("cb") + "';</script></body></html>"
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Language": []string{query.Get("lang")}},
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}, nil
+}
+
+func TestDetectReflections(t *testing.T) {
+	urlFake, _ := url.Parse(fakeLink)
+	crawler := NewCrawler(context.Background(), urlFake)
+	crawler.SetNumberOfThreads(2)
+	crawler.client = &httpClientEchoStub{}
+
+	link := fakeLink + "search?q=shoes&id=1&cb=fn&lang=en&sort=asc"
+	response := &Response{VisitedLink: NewLink(link), StatusCode: http.StatusOK}
+	crawler.Result.Store(link, response)
+	crawler.Result.Store(fakeLink, &Response{VisitedLink: NewLink(fakeLink), StatusCode: http.StatusOK})
+
+	crawler.DetectReflections()
+
+	require.Equal(t, []*Reflection{
+		{Param: "cb", Contexts: []string{ReflectionInScript}},
+		{Param: "id", Contexts: []string{ReflectionInAttribute}},
+		{Param: "lang", Contexts: []string{ReflectionInHeader}},
+		{Param: "q", Contexts: []string{ReflectionInHTML}},
+	}, response.Reflections, "should detect reflection contexts of each param")
+	require.True(t, response.HasAnyFeature(FeatureReflectedParam), "should set feature")
+}
+
+func TestDetectReflectionsBudget(t *testing.T) {
+	urlFake, _ := url.Parse(fakeLink)
+	crawler := NewCrawler(context.Background(), urlFake)
+	crawler.client = &httpClientEchoStub{}
+	crawler.Budget.MaxCanaries = 1
+
+	link := fakeLink + "search?q=shoes&id=1"
+	response := &Response{VisitedLink: NewLink(link), StatusCode: http.StatusOK}
+	crawler.Result.Store(link, response)
+
+	crawler.DetectReflections()
+	require.Len(t, response.Reflections, 1, "should not exceed canary requests budget")
+}
+
+func TestDetectReflectionsAfterCrawlBudget(t *testing.T) {
+	urlFake, _ := url.Parse(fakeLink)
+	crawler := NewCrawler(context.Background(), urlFake)
+	crawler.client = &httpClientEchoStub{}
+	crawler.Budget.MaxPages = 1
+	crawler.takePage()
+	require.False(t, crawler.takePage(), "crawl budget should be exhausted")
+
+	link := fakeLink + "search?q=shoes"
+	response := &Response{VisitedLink: NewLink(link), StatusCode: http.StatusOK}
+	crawler.Result.Store(link, response)
+
+	crawler.DetectReflections()
+	require.Len(t, response.Reflections, 1, "should use canary budget instead of crawl budget")
+}
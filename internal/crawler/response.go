@@ -1,32 +1,36 @@
 package crawler
 
 import (
+	"bytes"
 	"io"
-	"net/url"
+	"net/http"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
-//NumOfBodyParams size of array of test-services filter parameters
-const NumOfBodyParams = 3
-const (
-	HasFormTag        = iota //index of HasForm filter in array of test-services parameters
-	HasQueryParameter        //index of HasQuery filter
-	HasStatusError
-)
-
 //Response representation of a single crawler result
 type Response struct {
-	VisitedLink    *Link                 //link that was visited
-	StatusCode     int                   //http status code
-	BodyForQueries *goquery.Document     //body for further analysis with goquery lib
-	BodyParams     [NumOfBodyParams]bool //values with filter matching 0-has form, 1-has query param ...
+	VisitedLink    *Link             //link that was visited
+	StatusCode     int               //http status code
+	Header         http.Header       //http response headers
+	ContentType    string            //media type of the body from Content-Type header or sniffing, e.g. "text/html"
+	BodyForQueries *goquery.Document //body for further analysis with goquery lib
+	RawBody        []byte            //body as received, for passive checks
+	Features       FeatureSet        //features used to route the url between test-services, see [crawler.RegisterFeature]
+	Fingerprint    uint64            //simhash of the page DOM shape, see [crawler.Simhash]
+	Soft404        bool              //page looks like the host response for nonexistent paths
+	Reflections    []*Reflection     //query parameters reflected in the page, see [crawler.Crawler.DetectReflections]
+	Redirects      []*Redirect       //redirect chain followed to get the response, empty if there were no redirects
+	Digest         string            //hex sha256 of the raw body, empty if the body was not read
+	Change         Change            //change of the page since the previous crawl, empty if there is no previous crawl
+	Forms          []*Form           //forms of the page
 }
 
 //Link url to visit with jumps made to get to that url
 type Link struct {
-	URL   string //visited url
-	Jumps int    //depth where this very link was found on
+	URL       string //visited url
+	Jumps     int    //depth where this very link was found on
+	Extractor string //how the link was found, e.g. "a[href]", see [crawler.SiteGraph]
 }
 
 //NewLink is a [crawler.Link] constructor
@@ -50,57 +54,28 @@ func NewResponse(link *Link, status int) *Response {
 	}
 }
 
-//HasEqualParamsWith returns true if resp.BodyParams has at least one similar parameter to the given parameters, false - otherwise
-func (resp *Response) HasEqualParamsWith(comparedParams [NumOfBodyParams]bool) bool {
-	for i := 0; i < NumOfBodyParams; i++ {
-		if resp.BodyParams[i] && resp.BodyParams[i] == comparedParams[i] {
-			return true
-		}
-	}
-
-	return false
-}
-
 //FillResponseBody transforms given parameter to a resp.BodyForQueries for further goquery processing
-func (resp *Response) FillResponseBody(receivedBody io.ReadCloser) error {
-	queryDoc, err := goquery.NewDocumentFromReader(receivedBody)
+func (resp *Response) FillResponseBody(receivedBody io.Reader) error {
+	rawBody, err := io.ReadAll(receivedBody)
 	if err != nil {
 		return err
 	}
+	queryDoc, err := goquery.NewDocumentFromReader(bytes.NewReader(rawBody))
+	if err != nil {
+		return err
+	}
+	resp.RawBody = rawBody
 	resp.BodyForQueries = queryDoc
 
 	return nil
 }
 
-//FillResponseParameters calculates resp.BodyParams
+//FillResponseParameters calculates resp.Features, resp.Forms, resp.Fingerprint & resp.Digest
 func (resp *Response) FillResponseParameters() {
-	resp.fillHasFormTag()
-	resp.fillHasQueryParams()
-	resp.fillHasStatusError()
-}
-
-func (resp *Response) fillHasStatusError() {
-	resp.BodyParams[HasStatusError] = resp.StatusCode >= 500
-}
-
-func (resp *Response) fillHasFormTag() {
-	queryDoc := resp.BodyForQueries
-	if queryDoc == nil {
-		return
-	}
-
-	if len(queryDoc.Has("form").Nodes) > 0 {
-		resp.BodyParams[HasFormTag] = true
-	}
-}
-
-func (resp *Response) fillHasQueryParams() {
-	linkUrl, err := url.Parse(resp.VisitedLink.URL)
-	if err != nil {
-		return
-	}
-	params := linkUrl.Query()
-	resp.BodyParams[HasQueryParameter] = len(params) > 0
+	resp.extractFeatures()
+	resp.extractForms()
+	resp.fillFingerprint()
+	resp.fillDigest()
 }
 
 //ParseLinksFromResponse returns array of new url-links found in a given resp.BodyForQueries
@@ -116,18 +91,22 @@ func (resp *Response) ParseLinksFromResponse(crawl *Crawler) []*Link {
 	}
 
 	var result []*Link
-	queryDoc.Find(`[href]`).
+	queryDoc.Find(`[href], script[src]`).
 		EachWithBreak(func(i int, sel *goquery.Selection) bool {
 			linkURL := crawl.absoluteURL(sel.Text())
+			var extractor string
 			if len(sel.Nodes) > 0 {
 				for _, attr := range sel.Nodes[0].Attr {
-					if attr.Key == "href" {
+					if attr.Key == "href" || (attr.Key == "src" && sel.Nodes[0].Data == "script") {
 						linkURL = crawl.absoluteURL(attr.Val)
+						extractor = sel.Nodes[0].Data + "[" + attr.Key + "]"
 					}
 				}
 			}
 
-			result = append(result, NewLink(linkURL, linkDepth))
+			link := NewLink(linkURL, linkDepth)
+			link.Extractor = extractor
+			result = append(result, link)
 
 			return !crawl.shouldExit()
 		})
@@ -135,8 +114,9 @@ func (resp *Response) ParseLinksFromResponse(crawl *Crawler) []*Link {
 	return result
 }
 
-//ClearResponseBody deletes resp.BodyForQueries value for memory saving purposes
+//ClearResponseBody deletes resp.BodyForQueries & resp.RawBody values for memory saving purposes
 //used after resp.BodyForQueries is no longer needed
 func (resp *Response) ClearResponseBody() {
 	resp.BodyForQueries = nil
+	resp.RawBody = nil
 }
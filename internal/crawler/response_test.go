@@ -47,8 +47,7 @@ func TestContainsFormTag(t *testing.T) {
 
 	for _, test := range tabTest {
 		t.Run(test.name, func(t *testing.T) {
-			test.response.fillHasFormTag()
-			require.Equal(t, test.expected, test.response.BodyParams[HasFormTag], "should equal")
+			require.Equal(t, test.expected, hasFormTag(test.response), "should equal")
 		})
 	}
 }
@@ -58,7 +57,7 @@ func TestParseLinksFromResponse(t *testing.T) {
 	defer bodyWithLink.Close()
 	queryWithLink, _ := goquery.NewDocumentFromReader(bodyWithLink)
 
-	bodyWithMultipleLinks := ioutil.NopCloser(strings.NewReader("<a href='/'></a><a href='search'></a>"))
+	bodyWithMultipleLinks := ioutil.NopCloser(strings.NewReader("<a href='/'></a><script src='app.js'></script><a href='search'></a><img src='logo.png'>"))
 	defer bodyWithMultipleLinks.Close()
 	queryWithMultipleLinks, _ := goquery.NewDocumentFromReader(bodyWithMultipleLinks)
 	urlForCrawler, _ := url.Parse("https://www.google.com/")
@@ -75,7 +74,7 @@ func TestParseLinksFromResponse(t *testing.T) {
 			response: &Response{
 				BodyForQueries: queryWithLink,
 			},
-			expected: []*Link{NewLink("https://www.google.com/", 0)},
+			expected: []*Link{{URL: "https://www.google.com/", Extractor: "a[href]"}},
 		},
 		{
 			name:    "with multiple links",
@@ -84,8 +83,9 @@ func TestParseLinksFromResponse(t *testing.T) {
 				BodyForQueries: queryWithMultipleLinks,
 			},
 			expected: []*Link{
-				NewLink("https://www.google.com/", 0),
-				NewLink("https://www.google.com/search", 0),
+				{URL: "https://www.google.com/", Extractor: "a[href]"},
+				{URL: "https://www.google.com/app.js", Extractor: "script[src]"},
+				{URL: "https://www.google.com/search", Extractor: "a[href]"},
 			},
 		},
 		{
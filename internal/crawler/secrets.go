@@ -0,0 +1,147 @@
+package crawler
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"regexp"
+	"strings"
+)
+
+//FindingSecret type of [crawler.SecretsCheck] findings
+const FindingSecret = "secret"
+
+//ExtractorScriptSrc extractor of links to scripts, they are crawled for [crawler.SecretsCheck] only, see [crawler.Response.IsScript]
+const ExtractorScriptSrc = "script[src]"
+
+//secretVisibleChars number of characters kept on both sides of a masked secret
+const secretVisibleChars = 4
+
+//go:embed rules/secrets.json
+var defaultSecretRules []byte
+
+//SecretRule signature of a secret in response body, the first capture group (or the whole match) is the secret itself
+type SecretRule struct {
+	Name        string  `json:"name"`                 //rule name, reported as finding param
+	Pattern     string  `json:"pattern"`              //regular expression to search for
+	MinEntropy  float64 `json:"minEntropy,omitempty"` //min shannon entropy (bits per char) of the secret, 0 - no check
+	Severity    string  `json:"severity"`             //one of Severity... constants
+	Description string  `json:"description"`          //issue description, reported as finding payload
+	rgx         *regexp.Regexp
+}
+
+//SecretsCheck passive check for API keys, credentials, tokens & internal hostnames left in pages and scripts
+type SecretsCheck struct {
+	Rules []*SecretRule //rules to match response bodies against
+}
+
+//IsScript returns true if the response is a script, scripts are scanned for secrets but not routed to test-services
+func (resp *Response) IsScript() bool {
+	return (resp.VisitedLink != nil && resp.VisitedLink.Extractor == ExtractorScriptSrc) ||
+		strings.Contains(resp.ContentType, "javascript")
+}
+
+//LoadSecretRules reads rules from a given json file, from embedded default rules if path is empty
+func LoadSecretRules(path string) ([]*SecretRule, error) {
+	data := defaultSecretRules
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("error reading secret rules: %w", err)
+		}
+	}
+
+	var rules []*SecretRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("error parsing secret rules: %w", err)
+	}
+	for _, rule := range rules {
+		rgx, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("wrong pattern of secret rule %s: %w", rule.Name, err)
+		}
+		rule.rgx = rgx
+	}
+
+	return rules, nil
+}
+
+//NewSecretsCheck is a [crawler.SecretsCheck] constructor
+func NewSecretsCheck(rules []*SecretRule) *SecretsCheck {
+	return &SecretsCheck{Rules: rules}
+}
+
+//Type returns type of the check findings
+func (*SecretsCheck) Type() string {
+	return FindingSecret
+}
+
+//Check returns findings for secrets found in a given response body, each distinct secret is reported once
+func (check *SecretsCheck) Check(resp *Response) []*Finding {
+	if resp.VisitedLink == nil || len(resp.RawBody) == 0 {
+		return nil
+	}
+
+	var result []*Finding
+	for _, rule := range check.Rules {
+		for _, loc := range rule.rgx.FindAllSubmatchIndex(resp.RawBody, -1) {
+			start, end := loc[0], loc[1]
+			if len(loc) >= 4 && loc[2] >= 0 {
+				start, end = loc[2], loc[3]
+			}
+			secret := string(resp.RawBody[start:end])
+			if rule.MinEntropy > 0 && ShannonEntropy(secret) < rule.MinEntropy {
+				continue
+			}
+
+			line := bytes.Count(resp.RawBody[:start], []byte("\n")) + 1
+			log.Printf("Secret found (%s):\t%s:%d\t%s\n", rule.Name, resp.VisitedLink.URL, line, MaskSecret(secret))
+			result = append(result, &Finding{
+				Type:     check.Type(),
+				URL:      resp.VisitedLink.URL,
+				Scope:    secret,
+				Param:    rule.Name,
+				Payload:  rule.Description,
+				Evidence: fmt.Sprintf("%s:%d: %s", resp.VisitedLink.URL, line, snippet(resp.RawBody, start, end)),
+				Severity: rule.Severity,
+			})
+		}
+	}
+
+	return result
+}
+
+//ShannonEntropy returns entropy of a given string in bits per character
+func ShannonEntropy(value string) float64 {
+	if value == "" {
+		return 0
+	}
+
+	counts := map[rune]int{}
+	total := 0
+	for _, char := range value {
+		counts[char]++
+		total++
+	}
+
+	var result float64
+	for _, count := range counts {
+		frequency := float64(count) / float64(total)
+		result -= frequency * math.Log2(frequency)
+	}
+
+	return result
+}
+
+//MaskSecret hides the middle of a given secret, short secrets are masked completely
+func MaskSecret(secret string) string {
+	if len(secret) <= 3*secretVisibleChars {
+		return strings.Repeat("*", len(secret))
+	}
+
+	return secret[:secretVisibleChars] + strings.Repeat("*", len(secret)-2*secretVisibleChars) + secret[len(secret)-secretVisibleChars:]
+}
@@ -0,0 +1,113 @@
+package crawler
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+//MaxSoft404Distance max number of different bits of text fingerprints of a page & soft-404 baseline to consider them equal
+const MaxSoft404Distance = 3
+
+//soft404Paths templates of random nonexistent paths requested per host to build soft-404 baseline
+var soft404Paths = []string{"%s", "%s/%s", "%s.html"}
+
+//soft404Baseline responses of a server for nonexistent paths
+type soft404Baseline struct {
+	once    sync.Once
+	samples []*soft404Sample
+}
+
+type soft404Sample struct {
+	statusCode  int
+	fingerprint uint64
+	rawBody     []byte
+}
+
+//textFingerprint returns simhash of words of a given body with requested path removed (as soft-404 pages often echo it)
+func textFingerprint(body []byte, link string) uint64 {
+	text := string(body)
+	if parsedURL, err := url.Parse(link); err == nil && parsedURL.Path != "" && parsedURL.Path != "/" {
+		text = strings.ReplaceAll(text, parsedURL.EscapedPath(), "")
+		text = strings.ReplaceAll(text, parsedURL.Path, "")
+	}
+
+	return Simhash(strings.Fields(strings.ToLower(text)))
+}
+
+func randomPathName() string {
+	return "parabellum-" + uuid.NewString()
+}
+
+//soft404Baseline returns baseline for a given directory url (ending with "/"), requesting given paths once per directory
+func (cr *Crawler) soft404Baseline(dirURL string, paths ...string) *soft404Baseline {
+	value, _ := cr.baselines.LoadOrStore(dirURL, new(soft404Baseline))
+	baseline := value.(*soft404Baseline)
+	baseline.once.Do(func() {
+		for _, nonexistentPath := range paths {
+			link := dirURL + nonexistentPath
+			resp, err := cr.makeGetRequest(NewLink(link))
+			if err != nil {
+				continue
+			}
+			baseline.samples = append(baseline.samples, &soft404Sample{
+				statusCode:  resp.StatusCode,
+				fingerprint: textFingerprint(resp.RawBody, link),
+				rawBody:     resp.RawBody,
+			})
+		}
+	})
+
+	return baseline
+}
+
+//hostSoft404Baseline returns baseline for the host of a given link built from a few random nonexistent paths
+func (cr *Crawler) hostSoft404Baseline(link string) *soft404Baseline {
+	parsedURL, err := url.Parse(link)
+	if err != nil {
+		return new(soft404Baseline)
+	}
+
+	paths := make([]string, 0, len(soft404Paths))
+	for _, template := range soft404Paths {
+		paths = append(paths, strings.ReplaceAll(template, "%s", randomPathName()))
+	}
+
+	return cr.soft404Baseline(parsedURL.Scheme+"://"+parsedURL.Host+"/", paths...)
+}
+
+//markSoft404 sets resp.Soft404 if a successful response looks like the host response for nonexistent paths,
+//unchanged pages keep the mark of the previous crawl
+func (cr *Crawler) markSoft404(resp *Response) {
+	if !cr.DetectSoft404 || resp.Change == ChangeUnchanged || resp.StatusCode != http.StatusOK || resp.VisitedLink.URL == cr.URL.String() {
+		return
+	}
+
+	resp.Soft404 = cr.hostSoft404Baseline(resp.VisitedLink.URL).matches(resp)
+}
+
+//matches returns true if a given response looks like any of the baseline responses for nonexistent paths
+func (baseline *soft404Baseline) matches(resp *Response) bool {
+	fingerprint := textFingerprint(resp.RawBody, resp.VisitedLink.URL)
+	for _, sample := range baseline.samples {
+		if sample.statusCode == resp.StatusCode && HammingDistance(sample.fingerprint, fingerprint) <= MaxSoft404Distance {
+			return true
+		}
+	}
+
+	return false
+}
+
+//bodiesMatch returns true if any of the baseline bodies matches given matcher
+func (baseline *soft404Baseline) bodiesMatch(match func(body []byte) bool) bool {
+	for _, sample := range baseline.samples {
+		if match(sample.rawBody) {
+			return true
+		}
+	}
+
+	return false
+}
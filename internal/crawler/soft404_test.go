@@ -0,0 +1,75 @@
+package crawler
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkSoft404(t *testing.T) {
+	tabTests := []struct {
+		name     string
+		link     string
+		body     string
+		status   int
+		detect   bool
+		expected bool
+	}{
+		{
+			name:     "not found template",
+			link:     fakeLink + "no/such/page",
+			body:     softNotFoundBody + "<!-- /no/such/page -->",
+			status:   http.StatusOK,
+			detect:   true,
+			expected: true,
+		},
+		{
+			name:     "real page",
+			link:     fakeLink + "about",
+			body:     "<html><body><h1>About us</h1><p>We build tools for web application security testing</p></body></html>",
+			status:   http.StatusOK,
+			detect:   true,
+			expected: false,
+		},
+		{
+			name:     "root page is never soft-404",
+			link:     fakeLink,
+			body:     softNotFoundBody,
+			status:   http.StatusOK,
+			detect:   true,
+			expected: false,
+		},
+		{
+			name:     "real 404 status",
+			link:     fakeLink + "missing",
+			body:     softNotFoundBody,
+			status:   http.StatusNotFound,
+			detect:   true,
+			expected: false,
+		},
+		{
+			name:     "detection disabled",
+			link:     fakeLink + "no/such/page",
+			body:     softNotFoundBody,
+			status:   http.StatusOK,
+			detect:   false,
+			expected: false,
+		},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.name, func(t *testing.T) {
+			urlFake, _ := url.Parse(fakeLink)
+			crawler := NewCrawler(context.Background(), urlFake)
+			crawler.client = &httpClientSiteStub{}
+			crawler.DetectSoft404 = test.detect
+
+			response := &Response{VisitedLink: NewLink(test.link), StatusCode: test.status, RawBody: []byte(test.body)}
+			crawler.markSoft404(response)
+			require.Equal(t, test.expected, response.Soft404, "should be equal")
+		})
+	}
+}
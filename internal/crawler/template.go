@@ -0,0 +1,217 @@
+package crawler
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+//Parameter locations in [crawler.TemplateParam]
+const (
+	ParamInPath  = "path"
+	ParamInQuery = "query"
+)
+
+const (
+	MaxParamSamples    = 5 //max number of sample values kept for a single template parameter
+	MinSegmentVariants = 5 //min number of distinct literal segments under the same prefix to treat the segment as a parameter
+)
+
+//EndpointTemplate cluster of urls sharing the same endpoint, e.g. "/users/{int}/orders?page={int}"
+type EndpointTemplate struct {
+	Template string           //endpoint template itself
+	Params   []*TemplateParam //parameters observed in the template urls
+	URLs     []string         //sorted urls of the template
+}
+
+//TemplateParam parameter of [crawler.EndpointTemplate]
+type TemplateParam struct {
+	Name     string   //query key or name of the path segment (preceding literal segment)
+	In       string   //parameter location: ParamInPath or ParamInQuery
+	Position int      //index of path segment for path parameters
+	Type     string   //value type, one of ValueType... constants
+	Samples  []string //distinct observed values, no more than MaxParamSamples
+}
+
+type templateLink struct {
+	link     string
+	segments []string
+	query    url.Values
+	keys     []string
+	template []string
+}
+
+//EndpointTemplates infers endpoint templates from all urls in cr.Result
+func (cr *Crawler) EndpointTemplates() []*EndpointTemplate {
+	var links []string
+	cr.Result.Range(func(key, value any) bool {
+		if link, ok := key.(string); ok {
+			links = append(links, link)
+		}
+
+		return true
+	})
+
+	return InferTemplates(links)
+}
+
+//InferTemplates clusters given urls into endpoint templates by path segments & query keys
+func InferTemplates(links []string) []*EndpointTemplate {
+	byShape := map[string][]*templateLink{}
+	for _, link := range links {
+		parsed, err := newTemplateLink(link)
+		if err != nil {
+			continue
+		}
+		shape := strings.Repeat("/", len(parsed.segments)) + "?" + strings.Join(parsed.keys, "&")
+		byShape[shape] = append(byShape[shape], parsed)
+	}
+
+	byTemplate := map[string][]*templateLink{}
+	for _, shapeLinks := range byShape {
+		markVariableSegments(shapeLinks)
+		for _, tl := range shapeLinks {
+			key := tl.templateKey()
+			byTemplate[key] = append(byTemplate[key], tl)
+		}
+	}
+
+	result := make([]*EndpointTemplate, 0, len(byTemplate))
+	for _, templateLinks := range byTemplate {
+		result = append(result, newEndpointTemplate(templateLinks))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Template < result[j].Template
+	})
+
+	return result
+}
+
+func newTemplateLink(link string) (*templateLink, error) {
+	parsedURL, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+
+	tl := &templateLink{
+		link:     link,
+		segments: strings.Split(strings.TrimPrefix(parsedURL.Path, "/"), "/"),
+		query:    parsedURL.Query(),
+	}
+	for key := range tl.query {
+		tl.keys = append(tl.keys, key)
+	}
+	sort.Strings(tl.keys)
+	tl.template = make([]string, len(tl.segments))
+	for i, segment := range tl.segments {
+		tl.template[i] = segmentPlaceholder(segment)
+	}
+
+	return tl, nil
+}
+
+//markVariableSegments replaces literal segments with {str} placeholder where urls with the same prefix
+//have at least MinSegmentVariants distinct values at the position followed by the same suffix
+func markVariableSegments(links []*templateLink) {
+	if len(links) < MinSegmentVariants {
+		return
+	}
+
+	for pos := 1; pos < len(links[0].segments); pos++ {
+		byPrefix := map[string][]*templateLink{}
+		for _, tl := range links {
+			prefix := strings.Join(tl.template[:pos], "/")
+			byPrefix[prefix] = append(byPrefix[prefix], tl)
+		}
+		for _, siblings := range byPrefix {
+			if isVariablePosition(siblings, pos) {
+				for _, tl := range siblings {
+					tl.template[pos] = "{" + ValueTypeStr + "}"
+				}
+			}
+		}
+	}
+}
+
+func isVariablePosition(siblings []*templateLink, pos int) bool {
+	values := map[string]bool{}
+	suffixes := map[string]bool{}
+	for _, tl := range siblings {
+		values[tl.template[pos]] = true
+		suffixes[strings.Join(tl.template[pos+1:], "/")] = true
+	}
+
+	return len(values) >= MinSegmentVariants && len(suffixes) == 1
+}
+
+func (tl *templateLink) templateKey() string {
+	return "/" + strings.Join(tl.template, "/") + "?" + strings.Join(tl.keys, "&")
+}
+
+func newEndpointTemplate(links []*templateLink) *EndpointTemplate {
+	result := new(EndpointTemplate)
+	for _, tl := range links {
+		result.URLs = append(result.URLs, tl.link)
+	}
+	sort.Strings(result.URLs)
+
+	first := links[0]
+	for pos, segment := range first.template {
+		if !strings.HasPrefix(segment, "{") {
+			continue
+		}
+		param := &TemplateParam{Name: pathParamName(first.template, pos), In: ParamInPath, Position: pos}
+		for _, tl := range links {
+			param.addSample(tl.segments[pos])
+		}
+		result.Params = append(result.Params, param)
+	}
+	queryParts := make([]string, 0, len(first.keys))
+	for _, key := range first.keys {
+		param := &TemplateParam{Name: key, In: ParamInQuery}
+		for _, tl := range links {
+			param.addSample(tl.query.Get(key))
+		}
+		result.Params = append(result.Params, param)
+		queryParts = append(queryParts, key+"={"+param.Type+"}")
+	}
+
+	result.Template = "/" + strings.Join(first.template, "/")
+	if len(queryParts) > 0 {
+		result.Template += "?" + strings.Join(queryParts, "&")
+	}
+
+	return result
+}
+
+func pathParamName(template []string, pos int) string {
+	for i := pos - 1; i >= 0; i-- {
+		if !strings.HasPrefix(template[i], "{") && template[i] != "" {
+			return template[i]
+		}
+	}
+
+	return "segment"
+}
+
+func (param *TemplateParam) addSample(value string) {
+	valueType := ValueType(value)
+	switch {
+	case param.Type == "":
+		param.Type = valueType
+	case param.Type == ValueTypeInt && valueType == ValueTypeFloat:
+		param.Type = ValueTypeFloat
+	case param.Type != valueType && !(param.Type == ValueTypeFloat && valueType == ValueTypeInt):
+		param.Type = ValueTypeStr
+	}
+
+	if len(param.Samples) >= MaxParamSamples {
+		return
+	}
+	for _, sample := range param.Samples {
+		if sample == value {
+			return
+		}
+	}
+	param.Samples = append(param.Samples, value)
+}
@@ -0,0 +1,68 @@
+package crawler
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInferTemplates(t *testing.T) {
+	links := []string{
+		fakeLink + "users/1/orders?page=1",
+		fakeLink + "users/2/orders?page=2",
+		fakeLink + "about",
+	}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		links = append(links, fakeLink+"blog/"+name+"/comments")
+	}
+
+	templates := InferTemplates(links)
+	require.Len(t, templates, 3, "should cluster links into templates")
+
+	require.Equal(t, "/about", templates[0].Template, "static link should have own template")
+	require.Empty(t, templates[0].Params, "static link has no parameters")
+
+	require.Equal(t, "/blog/{str}/comments", templates[1].Template, "string segments should be merged")
+	require.Len(t, templates[1].URLs, 5, "should keep all template urls")
+	require.Equal(t, &TemplateParam{
+		Name:     "blog",
+		In:       ParamInPath,
+		Position: 1,
+		Type:     ValueTypeStr,
+		Samples:  []string{"a", "b", "c", "d", "e"},
+	}, templates[1].Params[0], "should describe path parameter")
+
+	require.Equal(t, "/users/{int}/orders?page={int}", templates[2].Template, "typed segments should be merged")
+	require.Equal(t, &TemplateParam{
+		Name:    "page",
+		In:      ParamInQuery,
+		Type:    ValueTypeInt,
+		Samples: []string{"1", "2"},
+	}, templates[2].Params[1], "should describe query parameter")
+}
+
+func TestEndpointTemplates(t *testing.T) {
+	crawler := NewCrawler(context.Background(), &url.URL{})
+	crawler.Result.Store(fakeLink+"item?id=1", &Response{})
+	crawler.Result.Store(fakeLink+"item?id=x", &Response{})
+
+	templates := crawler.EndpointTemplates()
+	require.Len(t, templates, 1, "should cluster crawler results")
+	require.Equal(t, "/item?id={str}", templates[0].Template, "mixed value types should become string")
+}
+
+func TestValueType(t *testing.T) {
+	for value, expected := range map[string]string{
+		"42":                                   ValueTypeInt,
+		"4.2":                                  ValueTypeFloat,
+		"true":                                 ValueTypeBool,
+		"c56a4180-65aa-42ec-a945-5fd21dec0538": ValueTypeUUID,
+		"0123456789abcdef0":                    ValueTypeHex,
+		"https://other.site/":                  ValueTypeURL,
+		"name":                                 ValueTypeStr,
+	} {
+		require.Equal(t, expected, ValueType(value), "wrong type of %s", value)
+	}
+}
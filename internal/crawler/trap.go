@@ -0,0 +1,186 @@
+package crawler
+
+import (
+	"hash/fnv"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+//Trap kinds reported in logs on suppression
+const (
+	TrapRepeatingSegments = "repeating path segments"
+	TrapGrowingValue      = "ever-growing query value"
+	TrapSessionID         = "session id in url"
+	TrapIdenticalPages    = "near-identical pages"
+)
+
+//SessionIDParams lowercase names of query parameters holding session ids, they are stripped from links
+var SessionIDParams = map[string]bool{
+	"jsessionid":   true,
+	"phpsessid":    true,
+	"aspsessionid": true,
+	"sessionid":    true,
+	"session_id":   true,
+	"sessid":       true,
+}
+
+//TrapDetector heuristics to detect crawler traps & infinite url spaces, zero limits disable corresponding checks
+type TrapDetector struct {
+	MaxSegmentRepeats int      //max number of times one path segment can occur in a link
+	MaxValueGrowth    int      //max number of times a query value can grow along a chain of parent to child links within one url pattern
+	MaxIdenticalPages int      //max number of pages with identical content within one url pattern
+	suppressed        sync.Map //suppressed url patterns
+	sessionParams     sync.Map //stripped session id params, logged once
+	growth            sync.Map //growth depth of query values along the chain of links they were found on
+	digests           sync.Map
+}
+
+//NewTrapDetector is a [crawler.TrapDetector] constructor with default limits
+func NewTrapDetector() *TrapDetector {
+	return &TrapDetector{
+		MaxSegmentRepeats: 3,
+		MaxValueGrowth:    3,
+		MaxIdenticalPages: 5,
+	}
+}
+
+//IsTrap returns true if a child link found on a parent page should not be visited
+func (td *TrapDetector) IsTrap(parent *Link, child *Link) bool {
+	if td == nil {
+		return false
+	}
+
+	pattern := URLPattern(child.URL)
+	if _, ok := td.suppressed.Load(pattern); ok {
+		return true
+	}
+
+	childURL, err := url.Parse(child.URL)
+	if err != nil {
+		return false
+	}
+	if td.hasRepeatingSegments(childURL.Path) {
+		td.suppress(pattern, TrapRepeatingSegments, child.URL)
+
+		return true
+	}
+	if parent != nil && td.hasGrowingValue(pattern, parent.URL, childURL) {
+		td.suppress(pattern, TrapGrowingValue, child.URL)
+
+		return true
+	}
+
+	return false
+}
+
+//ObservePage remembers page content digest & suppresses its url pattern if too many pages look identical
+func (td *TrapDetector) ObservePage(resp *Response) {
+	if td == nil || td.MaxIdenticalPages <= 0 || resp.BodyForQueries == nil || resp.VisitedLink == nil {
+		return
+	}
+
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(strings.Join(strings.Fields(resp.BodyForQueries.Text()), " ")))
+	pattern := URLPattern(resp.VisitedLink.URL)
+	key := pattern + "\x00" + string(hash.Sum(nil))
+
+	counter, _ := td.digests.LoadOrStore(key, new(int64))
+	if atomic.AddInt64(counter.(*int64), 1) >= int64(td.MaxIdenticalPages) {
+		td.suppress(pattern, TrapIdenticalPages, resp.VisitedLink.URL)
+	}
+}
+
+//StripSessionIDs removes session id parameters from given url keeping order of other parameters
+func (td *TrapDetector) StripSessionIDs(link *url.URL) {
+	if td == nil {
+		return
+	}
+
+	sample := link.String()
+	if idx := strings.Index(strings.ToLower(link.Path), ";jsessionid="); idx >= 0 {
+		logOnce(&td.sessionParams, ";jsessionid=", TrapSessionID, sample)
+		link.Path = link.Path[:idx]
+		link.RawPath = ""
+	}
+	if link.RawQuery == "" {
+		return
+	}
+
+	var kept []string
+	stripped := false
+	for _, param := range strings.Split(link.RawQuery, "&") {
+		key, _, _ := strings.Cut(param, "=")
+		if name, err := url.QueryUnescape(key); err == nil && SessionIDParams[strings.ToLower(name)] {
+			logOnce(&td.sessionParams, "?"+strings.ToLower(name)+"=", TrapSessionID, sample)
+			stripped = true
+
+			continue
+		}
+		kept = append(kept, param)
+	}
+	if stripped {
+		link.RawQuery = strings.Join(kept, "&")
+	}
+}
+
+func (td *TrapDetector) suppress(pattern, kind, sample string) {
+	logOnce(&td.suppressed, pattern, kind, sample)
+}
+
+//logOnce stores a given key into a given set & logs it if it's new
+func logOnce(set *sync.Map, key, kind, sample string) {
+	if _, loaded := set.LoadOrStore(key, kind); !loaded {
+		log.Printf("Crawler trap suppressed (%s):\t%s\tsample: %s\n", kind, key, sample)
+	}
+}
+
+func (td *TrapDetector) hasRepeatingSegments(path string) bool {
+	if td.MaxSegmentRepeats <= 0 {
+		return false
+	}
+
+	counts := map[string]int{}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" {
+			continue
+		}
+		counts[segment]++
+		if counts[segment] > td.MaxSegmentRepeats {
+			return true
+		}
+	}
+
+	return false
+}
+
+//hasGrowingValue tracks how many times a query value has grown along the chain of links leading to a child link,
+//a value contains & extends the same parent link value on each step, so siblings (facets, pagination) share one depth
+func (td *TrapDetector) hasGrowingValue(pattern, parentLink string, childURL *url.URL) bool {
+	parentURL, err := url.Parse(parentLink)
+	if td.MaxValueGrowth <= 0 || err != nil || URLPattern(parentLink) != pattern {
+		return false
+	}
+
+	parentQuery := parentURL.Query()
+	for key, values := range childURL.Query() {
+		parentValue := parentQuery.Get(key)
+		if len(values) == 0 || parentValue == "" ||
+			len(values[0]) <= len(parentValue) || !strings.Contains(values[0], parentValue) {
+			continue
+		}
+
+		depth := 1
+		if parentDepth, ok := td.growth.Load(pattern + "\x00" + key + "=" + parentValue); ok {
+			depth += parentDepth.(int)
+		}
+		td.growth.Store(pattern+"\x00"+key+"="+values[0], depth)
+		if depth > td.MaxValueGrowth {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,102 @@
+package crawler
+
+import (
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTrap(t *testing.T) {
+	tabTests := []struct {
+		name     string
+		links    [][2]string //parent & child links in order of discovery
+		expected bool
+	}{
+		{
+			name:     "regular link",
+			links:    [][2]string{{fakeLink, fakeLink + "a/b/c"}},
+			expected: false,
+		},
+		{
+			name:     "repeating segments",
+			links:    [][2]string{{fakeLink, fakeLink + "a/b/a/b/a/b/a/b"}},
+			expected: true,
+		},
+		{
+			name: "growing query value",
+			links: [][2]string{
+				{fakeLink + "?path=a", fakeLink + "?path=aa"},
+				{fakeLink + "?path=aa", fakeLink + "?path=aaa"},
+				{fakeLink + "?path=aaa", fakeLink + "?path=aaaa"},
+				{fakeLink + "?path=aaaa", fakeLink + "?path=aaaaa"},
+			},
+			expected: true,
+		},
+		{
+			name: "facets",
+			links: [][2]string{
+				{fakeLink + "?cat=shoes", fakeLink + "?cat=shoes-men"},
+				{fakeLink + "?cat=shoes", fakeLink + "?cat=shoes-women"},
+				{fakeLink + "?cat=shoes", fakeLink + "?cat=shoes-kids"},
+				{fakeLink + "?cat=shoes", fakeLink + "?cat=shoes-sale"},
+				{fakeLink + "?cat=shoes-men", fakeLink + "?cat=shoes-men-boots"},
+			},
+			expected: false,
+		},
+		{
+			name: "pagination",
+			links: [][2]string{
+				{fakeLink + "?page=1", fakeLink + "?page=10"},
+				{fakeLink + "?page=1", fakeLink + "?page=11"},
+				{fakeLink + "?page=1", fakeLink + "?page=12"},
+				{fakeLink + "?page=1", fakeLink + "?page=13"},
+			},
+			expected: false,
+		},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.name, func(t *testing.T) {
+			detector := NewTrapDetector()
+			var got bool
+			for _, link := range test.links {
+				got = detector.IsTrap(NewLink(link[0]), NewLink(link[1]))
+				if got {
+					break
+				}
+			}
+			require.Equal(t, test.expected, got, "should be equal")
+		})
+	}
+}
+
+func TestObservePage(t *testing.T) {
+	detector := NewTrapDetector()
+	detector.MaxIdenticalPages = 2
+
+	for _, link := range []string{fakeLink + "day/1", fakeLink + "day/2"} {
+		body := ioutil.NopCloser(strings.NewReader("<p>No events</p>"))
+		queryDoc, _ := goquery.NewDocumentFromReader(body)
+		detector.ObservePage(&Response{VisitedLink: NewLink(link), BodyForQueries: queryDoc})
+	}
+
+	require.True(t, detector.IsTrap(nil, NewLink(fakeLink+"day/3")), "pattern with identical pages should be suppressed")
+	require.False(t, detector.IsTrap(nil, NewLink(fakeLink+"month/3")), "other patterns should not be suppressed")
+}
+
+func TestStripSessionIDs(t *testing.T) {
+	detector := NewTrapDetector()
+	link, _ := url.Parse(fakeLink + "cart;jsessionid=F00?item=1&PHPSESSID=abc")
+	detector.StripSessionIDs(link)
+	require.Equal(t, fakeLink+"cart?item=1", link.String(), "should strip session ids")
+
+	link, _ = url.Parse(fakeLink + "list?z=1&sessionid=abc&a=2&sid=3")
+	detector.StripSessionIDs(link)
+	require.Equal(t, fakeLink+"list?z=1&a=2&sid=3", link.String(), "should keep order of other params & ambiguous names")
+	_, ok := detector.suppressed.Load("?sessionid=")
+	require.False(t, ok, "session params should not be stored as suppressed url patterns")
+}
@@ -6,16 +6,39 @@ import (
 
 //MessageConsume received messages representation
 type MessageConsume struct {
-	Key    string       //message key from pubsub provider
-	Value  *TaskConsume //message value
-	Time   time.Time    //time of the message
-	Origin any          //message itself (for committing)
+	Key       string       //message key from pubsub provider
+	Value     *TaskConsume //message value
+	Time      time.Time    //time of the message
+	Partition int          //partition of the message, committing a message commits all previous ones of its partition
+	Origin    any          //message itself (for committing)
 }
 
 //TaskConsume received task format
 type TaskConsume struct {
-	ID          string   `json:"id"`          //main task id
-	URL         string   `json:"url"`         //main task url to crawl
-	ForwardTo   []string `json:"forwardTo"`   //list of test-services topics names to send results to
-	SkipCrawler bool     `json:"skipCrawler"` //if no crawling needed, just forward to tests
+	ID          string        `json:"id"`                //main task id
+	URL         string        `json:"url"`               //main task url to crawl
+	ForwardTo   []string      `json:"forwardTo"`         //list of test-services topics names to send results to
+	SkipCrawler bool          `json:"skipCrawler"`       //if no crawling needed, just forward to tests
+	Options     *CrawlOptions `json:"options,omitempty"` //optional overrides of service crawl parameters
+}
+
+//CrawlOptions per-task crawl parameters, zero values mean "use service default"
+type CrawlOptions struct {
+	MaxDepth          int               `json:"maxDepth,omitempty"`          //max depth of visiting links found inside given url
+	MaxPages          int               `json:"maxPages,omitempty"`          //max number of pages to visit
+	Timeout           int               `json:"timeout,omitempty"`           //crawl timeout in seconds
+	Threads           int               `json:"threads,omitempty"`           //number of goroutines to crawl with
+	RateLimit         float64           `json:"rateLimit,omitempty"`         //max requests per second
+	Include           []string          `json:"include,omitempty"`           //regexps, only urls matching any of them are visited
+	Exclude           []string          `json:"exclude,omitempty"`           //regexps, urls matching any of them are skipped
+	Headers           map[string]string `json:"headers,omitempty"`           //extra headers to send with every request
+	UserAgent         string            `json:"userAgent,omitempty"`         //User-Agent header to send with every request
+	IncludeSoft404    bool              `json:"includeSoft404,omitempty"`    //forward pages looking like "not found" responses to tests
+	DetectReflections bool              `json:"detectReflections,omitempty"` //find query parameters reflected in pages for XSS-check
+	RecordHAR         bool              `json:"recordHar,omitempty"`         //record every request & response of the crawl into HAR file
+	SeedHAR           string            `json:"seedHar,omitempty"`           //name of HAR file in service artifacts directory to seed the crawl from
+	ExportGraph       string            `json:"exportGraph,omitempty"`       //format of site graph file to export: dot, graphml or json
+	Priority          string            `json:"priority,omitempty"`          //order of visiting found links: bfs or attack-surface
+	Incremental       bool              `json:"incremental,omitempty"`       //send conditional requests & reuse links of pages unchanged since the previous crawl
+	ChangedOnly       bool              `json:"changedOnly,omitempty"`       //forward only new or changed pages to tests in incremental crawl
 }
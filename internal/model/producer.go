@@ -16,8 +16,74 @@ type MessageProduce struct {
 
 //TaskProduce published task format
 type TaskProduce struct {
-	ID   string   `json:"id"`   //main task id
-	URLs []string `json:"urls"` //urls for the receiver to work with
+	ID           string                   `json:"id"`                     //main task id
+	URLs         []string                 `json:"urls"`                   //urls for the receiver to work with
+	StopReason   string                   `json:"stopReason,omitempty"`   //name of the budget that stopped the crawl, empty if completed
+	Groups       []*URLGroup              `json:"groups,omitempty"`       //groups of near-duplicate pages, only representatives are in URLs
+	Templates    []*Template              `json:"templates,omitempty"`    //endpoint templates of URLs to test each parameter once per template
+	Features     map[string][]string      `json:"features,omitempty"`     //names of features of each url, e.g. "form", "json"
+	Reflections  map[string][]*Reflection `json:"reflections,omitempty"`  //query parameters of each url reflected in the page
+	HAR          string                   `json:"har,omitempty"`          //path of HAR file with requests & responses of the crawl
+	Graph        string                   `json:"graph,omitempty"`        //path of site graph file of the crawl
+	PreviousTask string                   `json:"previousTask,omitempty"` //id of the crawl pages were compared with in incremental crawl
+	Diff         *CrawlDiff               `json:"diff,omitempty"`         //difference with the previous crawl, sent to diff topic only
+}
+
+//URLGroup urls with the same path template & similar pages
+type URLGroup struct {
+	Pattern string   `json:"pattern"` //url pattern, e.g. "/product?id"
+	URLs    []string `json:"urls"`    //all urls of the group
+}
+
+//Template endpoint template with parameters observed in its urls
+type Template struct {
+	Template  string           `json:"template"`  //template itself, e.g. "/users/{int}/orders?page={int}"
+	SampleURL string           `json:"sampleUrl"` //one of URLs matching the template
+	URLs      []string         `json:"urls"`      //URLs matching the template
+	Params    []*TemplateParam `json:"params"`    //template parameters
+}
+
+//TemplateParam parameter of an endpoint template
+type TemplateParam struct {
+	Name     string   `json:"name"`               //query key or name of the path segment
+	In       string   `json:"in"`                 //parameter location: "path" or "query"
+	Position *int     `json:"position,omitempty"` //index of path segment for path parameters, nil for query parameters
+	Type     string   `json:"type"`               //value type: int, float, bool, uuid, hex, url or str
+	Samples  []string `json:"samples"`            //observed values
+}
+
+//Reflection query parameter reflected in the page
+type Reflection struct {
+	Param    string   `json:"param"`    //query parameter name
+	Contexts []string `json:"contexts"` //where the value is reflected: html, attribute, script or header
+}
+
+//CrawlDiff attack surface difference between two crawls of the same url
+type CrawlDiff struct {
+	From      string          `json:"from,omitempty"` //id of the old task, empty if there is no old crawl
+	To        string          `json:"to"`             //id of the new task
+	Endpoints []*EndpointDiff `json:"endpoints"`      //added, removed & changed endpoints
+	Templates []*TemplateDiff `json:"templates"`      //added & removed endpoint templates
+}
+
+//EndpointDiff difference of an endpoint between two crawls
+type EndpointDiff struct {
+	Kind            string   `json:"kind"`                      //added, removed or changed
+	URL             string   `json:"url"`                       //canonical url
+	Template        string   `json:"template"`                  //endpoint template of the url
+	StatusFrom      int      `json:"statusFrom,omitempty"`      //status in the old crawl, 0 - endpoint was added
+	StatusTo        int      `json:"statusTo,omitempty"`        //status in the new crawl, 0 - endpoint was removed
+	AddedFeatures   []string `json:"addedFeatures,omitempty"`   //features the endpoint got
+	RemovedFeatures []string `json:"removedFeatures,omitempty"` //features the endpoint lost
+	AddedForms      []string `json:"addedForms,omitempty"`      //signatures of forms the endpoint got, e.g. "POST https://site/login (password, user)"
+	RemovedForms    []string `json:"removedForms,omitempty"`    //signatures of forms the endpoint lost
+}
+
+//TemplateDiff endpoint template appeared or disappeared between two crawls
+type TemplateDiff struct {
+	Kind     string   `json:"kind"`     //added or removed
+	Template string   `json:"template"` //endpoint template, e.g. "/users/{int}"
+	URLs     []string `json:"urls"`     //urls of the template in the crawl it is present in
 }
 
 //NewMessageProduce is a constructor for [model.MessageProduce]
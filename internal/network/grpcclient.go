@@ -63,6 +63,38 @@ func (cl *ClientGRPC) Push5XXResult(ctx context.Context, taskID string, fitRespo
 	return err
 }
 
+//PushFindings pushes findings of passive checks of a given result type to the result collector
+func (cl *ClientGRPC) PushFindings(ctx context.Context, taskID, resultType string, findings []*crawler.Finding) error {
+	results := make([]*pb.Result, 0, len(findings))
+	for _, finding := range findings {
+		results = append(results, &pb.Result{
+			URL:       finding.URL,
+			StartTime: timestamppb.New(time.Now()),
+			Type:      finding.Severity,
+			PoCs: []*pb.PoC{
+				{
+					Type:     finding.Type,
+					Param:    finding.Param,
+					Payload:  finding.Payload,
+					Data:     finding.URL,
+					Evidence: finding.Evidence,
+				},
+			},
+		})
+	}
+
+	req := &pb.PushResultReq{
+		ID: taskID,
+		TestResult: &pb.TestResult{
+			Type:    resultType,
+			Results: results,
+		},
+	}
+	_, err := cl.client.PushResult(ctx, req)
+
+	return err
+}
+
 func (cl *ClientGRPC) Close() error {
 	return cl.connection.Close()
 }
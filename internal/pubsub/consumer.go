@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"log"
 	"strings"
 
@@ -28,13 +29,23 @@ type Consumer struct {
 
 //RealKafkaReader returns filled kafka.Reader from kafka-go lib
 func RealKafkaReader(url, topic string) *kafka.Reader {
-	return kafka.NewReader(kafka.ReaderConfig{
+	return RealKafkaGroupReader(url, topic, groupID, false)
+}
+
+//RealKafkaGroupReader returns filled kafka.Reader of a given consumer group, new group starts from the latest messages if fromLatest is set
+func RealKafkaGroupReader(url, topic, group string, fromLatest bool) *kafka.Reader {
+	config := kafka.ReaderConfig{
 		Brokers:  strings.Split(url, ","),
 		Topic:    topic,
-		GroupID:  groupID,
+		GroupID:  group,
 		MinBytes: 10e3,
 		MaxBytes: 10e5,
-	})
+	}
+	if fromLatest {
+		config.StartOffset = kafka.LastOffset
+	}
+
+	return kafka.NewReader(config)
 }
 
 //NewConsumer is a constructor for [pubsub.Consumer]
@@ -63,6 +74,7 @@ func (cons *Consumer) FetchMessage(ctx context.Context) (*model.MessageConsume,
 	message.Key = string(msg.Key)
 	message.Value = task
 	message.Time = msg.Time
+	message.Partition = msg.Partition
 	message.Origin = &msg
 
 	log.Println("Read from Kafka. Task ID:", message.Value.ID)
@@ -70,6 +82,21 @@ func (cons *Consumer) FetchMessage(ctx context.Context) (*model.MessageConsume,
 	return message, nil
 }
 
+//FetchValue reads the next message as json into a given value, returned message has no Value & is used for committing
+func (cons *Consumer) FetchValue(ctx context.Context, value any) (*model.MessageConsume, error) {
+	msg, err := cons.kafkaReader.FetchMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &model.MessageConsume{Key: string(msg.Key), Time: msg.Time, Partition: msg.Partition, Origin: &msg}
+	if err = json.Unmarshal(msg.Value, value); err != nil {
+		return message, fmt.Errorf("error unmarshalling message of %s: %w", cons.Topic, err)
+	}
+
+	return message, nil
+}
+
 //CommitMessage commits given message, so it can be considered as processed
 func (cons *Consumer) CommitMessage(ctx context.Context, msg *model.MessageConsume) error {
 	m, ok := msg.Origin.(*kafka.Message)
@@ -105,3 +105,15 @@ func TestConsumerClose(t *testing.T) {
 
 	require.NoError(t, cons.Close(), "no error expected")
 }
+
+func TestFetchValue(t *testing.T) {
+	cons := NewConsumer(&kafkaReaderStub{valuePayload: `{"id":"test-task-1","url":"testurl"}`}, "sometopic")
+	value := new(model.TaskConsume)
+	msg, err := cons.FetchValue(context.Background(), value)
+	require.NoError(t, err, "no error expected")
+	require.NotNil(t, msg.Origin, "should keep origin for committing")
+	require.Equal(t, &model.TaskConsume{ID: "test-task-1", URL: "testurl"}, value, "should be equal")
+
+	_, err = NewConsumer(&kafkaReaderStub{}, "sometopic").FetchValue(context.Background(), value)
+	require.Error(t, err, "error expected on empty payload")
+}
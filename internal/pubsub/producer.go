@@ -3,7 +3,9 @@ package pubsub
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"log"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 	"parabellum.crawler/internal/model"
@@ -30,6 +32,15 @@ func RealKafkaWriter(url, topic string) *kafka.Writer {
 	}
 }
 
+//RealKafkaHashWriter returns filled kafka.Writer sending messages with the same key into the same partition
+func RealKafkaHashWriter(url, topic string) *kafka.Writer {
+	return &kafka.Writer{
+		Addr:     kafka.TCP(url),
+		Topic:    topic,
+		Balancer: &kafka.Hash{},
+	}
+}
+
 //NewProducer is a constructor for [pubsub.Producer]
 func NewProducer(kwr KafkaWriter, topic string) *Producer {
 	result := new(Producer)
@@ -64,6 +75,16 @@ func (prod *Producer) PublicMessage(ctx context.Context, message *model.MessageP
 	return prod.kafkaWriter.WriteMessages(ctx, msg)
 }
 
+//PublishValue sends given value as json with a given key into a [producer.Topic] topic
+func (prod *Producer) PublishValue(ctx context.Context, key string, value any) error {
+	valueJson, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("error marshalling value for %s: %w", prod.Topic, err)
+	}
+
+	return prod.kafkaWriter.WriteMessages(ctx, kafka.Message{Key: []byte(key), Value: valueJson, Time: time.Now()})
+}
+
 //Close closes producers' KafkaWriter
 func (prod *Producer) Close() error {
 	return prod.kafkaWriter.Close()
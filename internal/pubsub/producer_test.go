@@ -36,3 +36,10 @@ func TestProducerClose(t *testing.T) {
 
 	require.NoError(t, prod.Close(), "no error expected")
 }
+
+func TestPublishValue(t *testing.T) {
+	prod := NewProducer(&kafkaWriterStub{}, "test-topic")
+
+	require.NoError(t, prod.PublishValue(context.Background(), "key", map[string]string{"url": "url1"}), "no error expected")
+	require.Error(t, prod.PublishValue(context.Background(), "key", make(chan int)), "error expected on unmarshallable value")
+}
@@ -0,0 +1,210 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+	"parabellum.crawler/internal/crawler"
+)
+
+var (
+	bucketTasks     = []byte("tasks")     //task id -> Task
+	bucketEndpoints = []byte("endpoints") //task id -> bucket of url -> Endpoint
+	bucketEdges     = []byte("edges")     //task id -> bucket of from, to & extractor -> Edge
+)
+
+//BoltStore [store.CrawlStore] kept in a single bbolt file, the file is locked while the store is open
+type BoltStore struct {
+	db *bolt.DB
+}
+
+//OpenBoltStore is a [store.BoltStore] constructor, creates the file & its directory if needed
+func OpenBoltStore(path string) (*BoltStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("error creating store directory: %w", err)
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("error opening store %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{bucketTasks, bucketEndpoints, bucketEdges} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+
+		return nil, fmt.Errorf("error initializing store %s: %w", path, err)
+	}
+
+	return &BoltStore{db: db}, nil
+}
+
+//SaveCrawl saves task with its endpoints & edges in one transaction, replacing previously saved ones
+func (s *BoltStore) SaveCrawl(crawl *Crawl) error {
+	taskID := []byte(crawl.Task.ID)
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		if err := putJSON(tx.Bucket(bucketTasks), taskID, crawl.Task); err != nil {
+			return err
+		}
+
+		endpoints, err := recreateBucket(tx.Bucket(bucketEndpoints), taskID)
+		if err != nil {
+			return err
+		}
+		for _, endpoint := range crawl.Endpoints {
+			if err := putJSON(endpoints, []byte(endpoint.URL), endpoint); err != nil {
+				return err
+			}
+		}
+
+		edges, err := recreateBucket(tx.Bucket(bucketEdges), taskID)
+		if err != nil {
+			return err
+		}
+		for _, edge := range crawl.Edges {
+			if err := putJSON(edges, []byte(edge.From+"\x00"+edge.To+"\x00"+edge.Extractor), edge); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error saving task %s: %w", crawl.Task.ID, err)
+	}
+
+	return nil
+}
+
+//Tasks returns saved tasks, the latest first
+func (s *BoltStore) Tasks() ([]*Task, error) {
+	var result []*Task
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketTasks).ForEach(func(_, value []byte) error {
+			task := new(Task)
+			if err := json.Unmarshal(value, task); err != nil {
+				return err
+			}
+			result = append(result, task)
+
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading tasks: %w", err)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Started.After(result[j].Started) })
+
+	return result, nil
+}
+
+//Task returns saved task or ErrTaskNotFound
+func (s *BoltStore) Task(taskID string) (*Task, error) {
+	var result *Task
+	err := s.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(bucketTasks).Get([]byte(taskID))
+		if value == nil {
+			return ErrTaskNotFound
+		}
+		result = new(Task)
+
+		return json.Unmarshal(value, result)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading task %s: %w", taskID, err)
+	}
+
+	return result, nil
+}
+
+//Endpoints returns endpoints of a saved task sorted by url
+func (s *BoltStore) Endpoints(taskID string) ([]*Endpoint, error) {
+	var result []*Endpoint
+	err := s.forEachOfTask(bucketEndpoints, taskID, func(value []byte) error {
+		endpoint := new(Endpoint)
+		if err := json.Unmarshal(value, endpoint); err != nil {
+			return err
+		}
+		result = append(result, endpoint)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading endpoints of task %s: %w", taskID, err)
+	}
+
+	return result, nil
+}
+
+//Edges returns edges of a saved task sorted by urls
+func (s *BoltStore) Edges(taskID string) ([]*crawler.Edge, error) {
+	var result []*crawler.Edge
+	err := s.forEachOfTask(bucketEdges, taskID, func(value []byte) error {
+		edge := new(crawler.Edge)
+		if err := json.Unmarshal(value, edge); err != nil {
+			return err
+		}
+		result = append(result, edge)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading edges of task %s: %w", taskID, err)
+	}
+
+	return result, nil
+}
+
+//Close closes the store file
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+//forEachOfTask calls fn for values of a given task in a given top bucket ordered by key, ErrTaskNotFound if task is not saved
+func (s *BoltStore) forEachOfTask(name []byte, taskID string, fn func(value []byte) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		if tx.Bucket(bucketTasks).Get([]byte(taskID)) == nil {
+			return ErrTaskNotFound
+		}
+		bucket := tx.Bucket(name).Bucket([]byte(taskID))
+		if bucket == nil {
+			return nil
+		}
+
+		return bucket.ForEach(func(_, value []byte) error {
+			return fn(value)
+		})
+	})
+}
+
+func recreateBucket(parent *bolt.Bucket, name []byte) (*bolt.Bucket, error) {
+	if parent.Bucket(name) != nil {
+		if err := parent.DeleteBucket(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return parent.CreateBucket(name)
+}
+
+func putJSON(bucket *bolt.Bucket, key []byte, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return bucket.Put(key, data)
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"parabellum.crawler/internal/crawler"
+)
+
+const fakeLink = "https://this.is.link/"
+
+func newTestCrawl(taskID string, started time.Time, urls ...string) *Crawl {
+	crawl := &Crawl{Task: &Task{ID: taskID, URL: fakeLink, Started: started, Finished: started.Add(time.Minute), Endpoints: len(urls)}}
+	for _, link := range urls {
+		crawl.Endpoints = append(crawl.Endpoints, &Endpoint{URL: link, StatusCode: 200, Features: []string{"query-params"}})
+		crawl.Edges = append(crawl.Edges, &crawler.Edge{From: fakeLink, To: link, Extractor: "a[href]"})
+	}
+
+	return crawl
+}
+
+func TestBoltStore(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "data", "crawl.db")
+	s, err := OpenBoltStore(dbPath)
+	require.NoError(t, err, "no error expected")
+
+	started := time.Date(2022, 7, 20, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, s.SaveCrawl(newTestCrawl("task-1", started, fakeLink+"b?id=1", fakeLink+"a?id=1")), "no error expected")
+	require.NoError(t, s.SaveCrawl(newTestCrawl("task-2", started.Add(time.Hour), fakeLink+"c")), "no error expected")
+	require.NoError(t, s.Close(), "no error expected")
+
+	s, err = OpenBoltStore(dbPath)
+	require.NoError(t, err, "should reopen saved store")
+	defer s.Close()
+
+	tasks, err := s.Tasks()
+	require.NoError(t, err, "no error expected")
+	require.Len(t, tasks, 2, "should list saved tasks")
+	require.Equal(t, "task-2", tasks[0].ID, "the latest task should be the first")
+	require.True(t, started.Equal(tasks[1].Started), "should keep task start time")
+
+	endpoints, err := s.Endpoints("task-1")
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, []*Endpoint{
+		{URL: fakeLink + "a?id=1", StatusCode: 200, Features: []string{"query-params"}},
+		{URL: fakeLink + "b?id=1", StatusCode: 200, Features: []string{"query-params"}},
+	}, endpoints, "should return endpoints sorted by url")
+
+	edges, err := s.Edges("task-1")
+	require.NoError(t, err, "no error expected")
+	require.Len(t, edges, 2, "should return saved edges")
+	require.Equal(t, fakeLink+"a?id=1", edges[0].To, "should return edges sorted by urls")
+
+	require.NoError(t, s.SaveCrawl(newTestCrawl("task-1", started, fakeLink+"d")), "no error expected")
+	endpoints, err = s.Endpoints("task-1")
+	require.NoError(t, err, "no error expected")
+	require.Len(t, endpoints, 1, "saving the task again should replace its endpoints")
+
+	_, err = s.Task("task-3")
+	require.ErrorIs(t, err, ErrTaskNotFound, "should fail on unknown task")
+	_, err = s.Endpoints("task-3")
+	require.ErrorIs(t, err, ErrTaskNotFound, "should fail on unknown task")
+}
+
+func TestNewCrawl(t *testing.T) {
+	urlFake, _ := url.Parse(fakeLink)
+	cr := crawler.NewCrawler(context.Background(), urlFake)
+	cr.Result = new(sync.Map)
+	cr.Graph = crawler.NewSiteGraph()
+	cr.Graph.AddEdge(fakeLink, fakeLink+"a", "a[href]")
+
+	resp := crawler.NewResponse(crawler.NewLink(fakeLink+"a"), 500)
+	resp.SetFeature(crawler.FeatureStatusError)
+	cr.Result.Store(fakeLink+"a", resp)
+	cr.Result.Store(fakeLink, crawler.NewResponse(crawler.NewLink(fakeLink), 200))
+	cr.Result.Store(fakeLink+"pending", &crawler.Response{})
+
+	crawl := NewCrawl("task-1", time.Now(), cr)
+
+	require.Equal(t, "task-1", crawl.Task.ID, "should set task id")
+	require.Equal(t, fakeLink, crawl.Task.URL, "should set crawled url")
+	require.Equal(t, 2, crawl.Task.Endpoints, "should skip responses without visited link")
+	require.Equal(t, []*Endpoint{
+		{URL: fakeLink, StatusCode: 200, Features: []string{}},
+		{URL: fakeLink + "a", StatusCode: 500, Features: []string{"status-error"}},
+	}, crawl.Endpoints, "should convert responses sorted by url")
+	require.Equal(t, []*crawler.Edge{{From: fakeLink, To: fakeLink + "a", Extractor: "a[href]"}}, crawl.Edges, "should take graph edges")
+}
+
+func TestPreviousPages(t *testing.T) {
+	s, err := OpenBoltStore(filepath.Join(t.TempDir(), "crawl.db"))
+	require.NoError(t, err, "no error expected")
+	defer s.Close()
+
+	started := time.Date(2022, 7, 20, 10, 0, 0, 0, time.UTC)
+	crawl := newTestCrawl("task-1", started, fakeLink, fakeLink+"a")
+	crawl.Endpoints[0].ETag, crawl.Endpoints[0].Digest = `"v1"`, "abc"
+	crawl.Endpoints[1].ETag = `"v2"`
+	crawl.Endpoints[1].Redirects = []*crawler.Redirect{{URL: fakeLink + "a", StatusCode: 302, Location: fakeLink + "b"}}
+	require.NoError(t, s.SaveCrawl(crawl), "no error expected")
+	require.NoError(t, s.SaveCrawl(newTestCrawl("task-2", started.Add(time.Hour), fakeLink+"c")), "no error expected")
+	other := newTestCrawl("task-3", started.Add(2*time.Hour))
+	other.Task.URL = "https://other.site/"
+	require.NoError(t, s.SaveCrawl(other), "no error expected")
+
+	latest, err := LatestTask(s, fakeLink)
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, "task-2", latest.ID, "should find the latest task of the url")
+	_, err = LatestTask(s, "https://unknown.site/")
+	require.ErrorIs(t, err, ErrTaskNotFound, "should fail on url that was not crawled")
+
+	pages, err := PreviousPages(s, "task-1")
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, &crawler.PreviousPage{
+		StatusCode: 200,
+		ETag:       `"v1"`,
+		Digest:     "abc",
+		Features:   []crawler.Feature{crawler.FeatureQueryParams},
+		Links: []*crawler.Link{
+			{URL: fakeLink, Extractor: "a[href]"},
+			{URL: fakeLink + "a", Extractor: "a[href]"},
+		},
+	}, pages[fakeLink], "should convert endpoint & its outgoing edges")
+	require.Empty(t, pages[fakeLink+"a"].ETag, "should not send validators for redirected pages")
+}
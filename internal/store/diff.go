@@ -0,0 +1,227 @@
+package store
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"parabellum.crawler/internal/crawler"
+)
+
+//Kinds of differences between two crawls
+const (
+	DiffAdded   = "added"   //endpoint or template appeared in the new crawl
+	DiffRemoved = "removed" //endpoint or template disappeared from the new crawl
+	DiffChanged = "changed" //status, features or forms of the endpoint differ
+)
+
+//CrawlDiff attack surface difference between two crawls
+type CrawlDiff struct {
+	From      string          `json:"from,omitempty"` //id of the old task, empty if there is no old crawl
+	To        string          `json:"to"`             //id of the new task
+	Endpoints []*EndpointDiff `json:"endpoints"`      //added, removed & changed endpoints sorted by url
+	Templates []*TemplateDiff `json:"templates"`      //added & removed endpoint templates sorted by template
+}
+
+//EndpointDiff difference of an endpoint between two crawls
+type EndpointDiff struct {
+	Kind            string   `json:"kind"`                      //DiffAdded, DiffRemoved or DiffChanged
+	URL             string   `json:"url"`                       //canonical url, see [store.CanonicalURL]
+	Template        string   `json:"template"`                  //endpoint template of the url
+	StatusFrom      int      `json:"statusFrom,omitempty"`      //status in the old crawl, 0 - endpoint was added
+	StatusTo        int      `json:"statusTo,omitempty"`        //status in the new crawl, 0 - endpoint was removed
+	AddedFeatures   []string `json:"addedFeatures,omitempty"`   //features the endpoint got
+	RemovedFeatures []string `json:"removedFeatures,omitempty"` //features the endpoint lost
+	AddedForms      []string `json:"addedForms,omitempty"`      //signatures of forms the endpoint got, see [crawler.Form.String]
+	RemovedForms    []string `json:"removedForms,omitempty"`    //signatures of forms the endpoint lost
+}
+
+//TemplateDiff endpoint template appeared or disappeared between two crawls
+type TemplateDiff struct {
+	Kind     string   `json:"kind"`     //DiffAdded or DiffRemoved
+	Template string   `json:"template"` //endpoint template, e.g. "/users/{int}"
+	URLs     []string `json:"urls"`     //canonical urls of the template in the crawl it is present in
+}
+
+//CanonicalURL returns url with lower-case scheme & host, without default port & fragment, with sorted query parameters
+func CanonicalURL(link string) string {
+	parsedURL, err := url.Parse(link)
+	if err != nil || parsedURL.Host == "" {
+		return link
+	}
+
+	parsedURL.Scheme = strings.ToLower(parsedURL.Scheme)
+	host, port := strings.ToLower(parsedURL.Hostname()), parsedURL.Port()
+	if (parsedURL.Scheme == "http" && port == "80") || (parsedURL.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	parsedURL.Host = host
+	if port != "" {
+		parsedURL.Host = host + ":" + port
+	}
+	if parsedURL.Path == "" {
+		parsedURL.Path = "/"
+	}
+	parsedURL.RawQuery = parsedURL.Query().Encode()
+	parsedURL.Fragment = ""
+
+	return parsedURL.String()
+}
+
+//DiffTasks compares endpoints of two saved tasks, fromID may be empty to report all endpoints of the new task as added
+func DiffTasks(crawlStore CrawlStore, fromID, toID string) (*CrawlDiff, error) {
+	var from []*Endpoint
+	if fromID != "" {
+		var err error
+		if from, err = crawlStore.Endpoints(fromID); err != nil {
+			return nil, err
+		}
+	}
+	to, err := crawlStore.Endpoints(toID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := Diff(from, to)
+	result.From, result.To = fromID, toID
+
+	return result, nil
+}
+
+//PreviousTask returns the latest saved task of the same url started before a given one, ErrTaskNotFound if there is none
+func PreviousTask(crawlStore CrawlStore, task *Task) (*Task, error) {
+	tasks, err := crawlStore.Tasks()
+	if err != nil {
+		return nil, err
+	}
+	for _, prev := range tasks {
+		if prev.ID != task.ID && prev.URL == task.URL && prev.Started.Before(task.Started) {
+			return prev, nil
+		}
+	}
+
+	return nil, ErrTaskNotFound
+}
+
+//Diff compares endpoints of two crawls by canonical url & endpoint template
+func Diff(from, to []*Endpoint) *CrawlDiff {
+	fromByURL, toByURL := endpointsByCanonicalURL(from), endpointsByCanonicalURL(to)
+	links := make([]string, 0, len(fromByURL)+len(toByURL))
+	for link := range fromByURL {
+		links = append(links, link)
+	}
+	for link := range toByURL {
+		if _, ok := fromByURL[link]; !ok {
+			links = append(links, link)
+		}
+	}
+
+	result := &CrawlDiff{Endpoints: []*EndpointDiff{}, Templates: []*TemplateDiff{}}
+	templateOf := map[string]string{}
+	for _, template := range crawler.InferTemplates(links) {
+		var fromURLs, toURLs []string
+		for _, link := range template.URLs {
+			templateOf[link] = template.Template
+			if _, ok := fromByURL[link]; ok {
+				fromURLs = append(fromURLs, link)
+			}
+			if _, ok := toByURL[link]; ok {
+				toURLs = append(toURLs, link)
+			}
+		}
+		switch {
+		case len(fromURLs) == 0:
+			result.Templates = append(result.Templates, &TemplateDiff{Kind: DiffAdded, Template: template.Template, URLs: toURLs})
+		case len(toURLs) == 0:
+			result.Templates = append(result.Templates, &TemplateDiff{Kind: DiffRemoved, Template: template.Template, URLs: fromURLs})
+		}
+	}
+
+	sort.Strings(links)
+	for _, link := range links {
+		if diff := diffEndpoint(fromByURL[link], toByURL[link]); diff != nil {
+			diff.URL, diff.Template = link, templateOf[link]
+			result.Endpoints = append(result.Endpoints, diff)
+		}
+	}
+
+	return result
+}
+
+//endpointsByCanonicalURL maps endpoints by canonical url, the first endpoint of sorted ones is kept for urls with the same canonical form
+func endpointsByCanonicalURL(endpoints []*Endpoint) map[string]*Endpoint {
+	sorted := append([]*Endpoint(nil), endpoints...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].URL < sorted[j].URL })
+
+	result := make(map[string]*Endpoint, len(sorted))
+	for _, endpoint := range sorted {
+		link := CanonicalURL(endpoint.URL)
+		if _, ok := result[link]; !ok {
+			result[link] = endpoint
+		}
+	}
+
+	return result
+}
+
+//diffEndpoint returns difference of the endpoint in two crawls, nil if there is no difference
+func diffEndpoint(from, to *Endpoint) *EndpointDiff {
+	if from == nil {
+		from = new(Endpoint)
+	}
+	if to == nil {
+		to = new(Endpoint)
+	}
+
+	result := &EndpointDiff{StatusFrom: from.StatusCode, StatusTo: to.StatusCode}
+	result.AddedFeatures, result.RemovedFeatures = diffStrings(from.Features, to.Features)
+	result.AddedForms, result.RemovedForms = diffStrings(formSignatures(from.Forms), formSignatures(to.Forms))
+
+	switch {
+	case from.URL == "":
+		result.Kind = DiffAdded
+	case to.URL == "":
+		result.Kind = DiffRemoved
+	case from.StatusCode != to.StatusCode ||
+		len(result.AddedFeatures)+len(result.RemovedFeatures)+len(result.AddedForms)+len(result.RemovedForms) > 0:
+		result.Kind = DiffChanged
+	default:
+		return nil
+	}
+
+	return result
+}
+
+func formSignatures(forms []*crawler.Form) []string {
+	result := make([]string, 0, len(forms))
+	for _, form := range forms {
+		result = append(result, form.String())
+	}
+
+	return result
+}
+
+//diffStrings returns sorted values present only in to & only in from
+func diffStrings(from, to []string) (added, removed []string) {
+	inFrom, inTo := map[string]bool{}, map[string]bool{}
+	for _, value := range from {
+		inFrom[value] = true
+	}
+	for _, value := range to {
+		inTo[value] = true
+	}
+	for value := range inTo {
+		if !inFrom[value] {
+			added = append(added, value)
+		}
+	}
+	for value := range inFrom {
+		if !inTo[value] {
+			removed = append(removed, value)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	return added, removed
+}
@@ -0,0 +1,89 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"parabellum.crawler/internal/crawler"
+)
+
+func TestCanonicalURL(t *testing.T) {
+	tabTests := []struct {
+		link     string
+		expected string
+	}{
+		{link: "HTTPS://This.Is.Link:443", expected: "https://this.is.link/"},
+		{link: "http://this.is.link:8080/a?b=2&a=1#top", expected: "http://this.is.link:8080/a?a=1&b=2"},
+		{link: "http://this.is.link:80/a/", expected: "http://this.is.link/a/"},
+		{link: "/relative", expected: "/relative"},
+	}
+
+	for _, test := range tabTests {
+		t.Run(test.link, func(t *testing.T) {
+			require.Equal(t, test.expected, CanonicalURL(test.link), "should be equal")
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	login := &crawler.Form{Action: fakeLink + "login", Method: "POST", Fields: []string{"password", "user"}}
+	from := []*Endpoint{
+		{URL: fakeLink, StatusCode: 200},
+		{URL: fakeLink + "old", StatusCode: 200},
+		{URL: fakeLink + "search?b=1&a=2", StatusCode: 200, Features: []string{"query-params"}},
+		{URL: fakeLink + "account", StatusCode: 200, Features: []string{"form"}},
+	}
+	to := []*Endpoint{
+		{URL: "HTTPS://this.is.link:443/", StatusCode: 200},
+		{URL: fakeLink + "search?a=2&b=1", StatusCode: 200, Features: []string{"query-params"}},
+		{URL: fakeLink + "account", StatusCode: 500, Features: []string{"form", "login-form", "status-error"}, Forms: []*crawler.Form{login}},
+		{URL: fakeLink + "admin/users", StatusCode: 200},
+	}
+
+	diff := Diff(from, to)
+
+	require.Equal(t, []*EndpointDiff{
+		{
+			Kind: DiffChanged, URL: fakeLink + "account", Template: "/account", StatusFrom: 200, StatusTo: 500,
+			AddedFeatures: []string{"login-form", "status-error"}, AddedForms: []string{login.String()},
+		},
+		{Kind: DiffAdded, URL: fakeLink + "admin/users", Template: "/admin/users", StatusTo: 200},
+		{Kind: DiffRemoved, URL: fakeLink + "old", Template: "/old", StatusFrom: 200},
+	}, diff.Endpoints, "should compare endpoints by canonical url")
+	require.Equal(t, []*TemplateDiff{
+		{Kind: DiffAdded, Template: "/admin/users", URLs: []string{fakeLink + "admin/users"}},
+		{Kind: DiffRemoved, Template: "/old", URLs: []string{fakeLink + "old"}},
+	}, diff.Templates, "should report templates present in one crawl only")
+
+	require.Len(t, Diff(nil, to).Endpoints, len(to), "without old crawl all endpoints are added")
+	require.Empty(t, Diff(to, to).Endpoints, "no difference expected")
+}
+
+func TestDiffTasks(t *testing.T) {
+	s, err := OpenBoltStore(filepath.Join(t.TempDir(), "crawl.db"))
+	require.NoError(t, err, "no error expected")
+	defer s.Close()
+
+	started := time.Date(2022, 7, 20, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, s.SaveCrawl(newTestCrawl("task-1", started, fakeLink+"a", fakeLink+"b")), "no error expected")
+	require.NoError(t, s.SaveCrawl(newTestCrawl("task-2", started.Add(time.Hour), fakeLink+"b", fakeLink+"c")), "no error expected")
+	task, err := s.Task("task-2")
+	require.NoError(t, err, "no error expected")
+
+	prev, err := PreviousTask(s, task)
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, "task-1", prev.ID, "should find the crawl of the same url started earlier")
+	_, err = PreviousTask(s, prev)
+	require.ErrorIs(t, err, ErrTaskNotFound, "the first crawl has no previous one")
+
+	diff, err := DiffTasks(s, prev.ID, task.ID)
+	require.NoError(t, err, "no error expected")
+	require.Equal(t, "task-1", diff.From, "should set old task id")
+	require.Equal(t, "task-2", diff.To, "should set new task id")
+	require.Len(t, diff.Endpoints, 2, "one endpoint added, one removed")
+
+	_, err = DiffTasks(s, "task-3", task.ID)
+	require.ErrorIs(t, err, ErrTaskNotFound, "should fail on unknown task")
+}
@@ -0,0 +1,159 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"parabellum.crawler/internal/crawler"
+)
+
+//ErrTaskNotFound predefined error for queries of tasks that were not saved
+var ErrTaskNotFound = errors.New("task not found")
+
+//CrawlStore persists results of crawls to query them after the task is done
+type CrawlStore interface {
+	SaveCrawl(crawl *Crawl) error                 //saves task with its endpoints & edges, replacing previously saved ones
+	Tasks() ([]*Task, error)                      //returns saved tasks, the latest first
+	Task(taskID string) (*Task, error)            //returns saved task or ErrTaskNotFound
+	Endpoints(taskID string) ([]*Endpoint, error) //returns endpoints of a saved task sorted by url
+	Edges(taskID string) ([]*crawler.Edge, error) //returns edges of a saved task sorted by urls
+	Close() error
+}
+
+//Task metadata of a crawl
+type Task struct {
+	ID         string    `json:"id"`                   //main task id
+	URL        string    `json:"url"`                  //crawled url
+	Started    time.Time `json:"started"`              //time the crawl started
+	Finished   time.Time `json:"finished"`             //time the crawl finished
+	StopReason string    `json:"stopReason,omitempty"` //name of the budget that stopped the crawl, empty if completed
+	Endpoints  int       `json:"endpoints"`            //number of saved endpoints
+}
+
+//Endpoint crawled url with parameters of its response
+type Endpoint struct {
+	URL          string                `json:"url"`                    //visited url
+	StatusCode   int                   `json:"status"`                 //http status code
+	ContentType  string                `json:"contentType,omitempty"`  //media type of the body
+	Features     []string              `json:"features,omitempty"`     //sorted names of features, see [crawler.FeatureSet]
+	Fingerprint  uint64                `json:"fingerprint,omitempty"`  //simhash of the page DOM shape
+	Soft404      bool                  `json:"soft404,omitempty"`      //page looks like the host response for nonexistent paths
+	Redirects    []*crawler.Redirect   `json:"redirects,omitempty"`    //redirect chain followed to get the response
+	Reflections  []*crawler.Reflection `json:"reflections,omitempty"`  //query parameters reflected in the page
+	ETag         string                `json:"etag,omitempty"`         //ETag response header
+	LastModified string                `json:"lastModified,omitempty"` //Last-Modified response header
+	Digest       string                `json:"digest,omitempty"`       //hex sha256 of the body
+	Change       crawler.Change        `json:"change,omitempty"`       //change since the previous crawl, empty if crawl was not incremental
+	Forms        []*crawler.Form       `json:"forms,omitempty"`        //forms of the page
+}
+
+//Crawl task with its results to save
+type Crawl struct {
+	Task      *Task
+	Endpoints []*Endpoint
+	Edges     []*crawler.Edge
+}
+
+//NewCrawl collects results of a finished crawler into [store.Crawl]
+func NewCrawl(taskID string, started time.Time, cr *crawler.Crawler) *Crawl {
+	var endpoints []*Endpoint
+	cr.Result.Range(func(key, value any) bool {
+		if resp, ok := value.(*crawler.Response); ok && resp.VisitedLink != nil {
+			endpoints = append(endpoints, NewEndpoint(resp))
+		}
+
+		return true
+	})
+	sort.Slice(endpoints, func(i, j int) bool { return endpoints[i].URL < endpoints[j].URL })
+
+	var edges []*crawler.Edge
+	if cr.Graph != nil {
+		edges = cr.Graph.Edges()
+	}
+
+	return &Crawl{
+		Task: &Task{
+			ID:         taskID,
+			URL:        cr.URL.String(),
+			Started:    started,
+			Finished:   time.Now(),
+			StopReason: cr.StopReason(),
+			Endpoints:  len(endpoints),
+		},
+		Endpoints: endpoints,
+		Edges:     edges,
+	}
+}
+
+//NewEndpoint is a [store.Endpoint] constructor from a crawled response
+func NewEndpoint(resp *crawler.Response) *Endpoint {
+	return &Endpoint{
+		URL:          resp.VisitedLink.URL,
+		StatusCode:   resp.StatusCode,
+		ContentType:  resp.ContentType,
+		Features:     resp.Features.Names(),
+		Fingerprint:  resp.Fingerprint,
+		Soft404:      resp.Soft404,
+		Redirects:    resp.Redirects,
+		Reflections:  resp.Reflections,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Digest:       resp.Digest,
+		Change:       resp.Change,
+		Forms:        resp.Forms,
+	}
+}
+
+//LatestTask returns the latest saved task of a given crawled url, ErrTaskNotFound if there is none
+func LatestTask(crawlStore CrawlStore, target string) (*Task, error) {
+	tasks, err := crawlStore.Tasks()
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		if task.URL == target {
+			return task, nil
+		}
+	}
+
+	return nil, ErrTaskNotFound
+}
+
+//PreviousPages returns pages of a saved task for incremental crawling, see [crawler.Crawler.Previous]
+func PreviousPages(crawlStore CrawlStore, taskID string) (crawler.PreviousCrawl, error) {
+	endpoints, err := crawlStore.Endpoints(taskID)
+	if err != nil {
+		return nil, err
+	}
+	edges, err := crawlStore.Edges(taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(crawler.PreviousCrawl, len(endpoints))
+	for _, endpoint := range endpoints {
+		prev := &crawler.PreviousPage{
+			StatusCode:  endpoint.StatusCode,
+			ContentType: endpoint.ContentType,
+			Digest:      endpoint.Digest,
+			Fingerprint: endpoint.Fingerprint,
+			Soft404:     endpoint.Soft404,
+			Forms:       endpoint.Forms,
+		}
+		if len(endpoint.Redirects) == 0 {
+			prev.ETag, prev.LastModified = endpoint.ETag, endpoint.LastModified
+		}
+		for _, name := range endpoint.Features {
+			prev.Features = append(prev.Features, crawler.Feature(name))
+		}
+		result[endpoint.URL] = prev
+	}
+	for _, edge := range edges {
+		if prev, ok := result[edge.From]; ok {
+			prev.Links = append(prev.Links, &crawler.Link{URL: edge.To, Extractor: edge.Extractor})
+		}
+	}
+
+	return result, nil
+}